@@ -0,0 +1,423 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// activityStep is a stage in the life of a simulated activity.
+type activityStep struct {
+	// State is "pending", "in_progress", "complete" or "cancelled".
+	State string
+	// Result is "success" or "failure", once the activity is complete.
+	Result            string
+	CompletionPercent int
+	// Log holds messages which appear in the activity log at this step.
+	Log []string
+}
+
+// activitySucceeds returns the steps of an activity which is pending, then in
+// progress (printing the log), then completes successfully.
+func activitySucceeds(log ...string) []activityStep {
+	return []activityStep{
+		{State: "pending"},
+		{State: "in_progress", CompletionPercent: 50, Log: log},
+		{State: "complete", Result: "success", CompletionPercent: 100},
+	}
+}
+
+// activityFails returns the steps of an activity which is pending, then in
+// progress (printing the log), then completes with a failure.
+func activityFails(log ...string) []activityStep {
+	return []activityStep{
+		{State: "pending"},
+		{State: "in_progress", CompletionPercent: 50, Log: log},
+		{State: "complete", Result: "failure", CompletionPercent: 100},
+	}
+}
+
+// activitySpec describes an activity to simulate.
+type activitySpec struct {
+	Type string
+	// Description may contain tags, e.g. "<user>Mock User</user>", as in the API.
+	Description string
+	Payload     map[string]any
+	// Integration is the ID of the integration which the activity belongs
+	// to, if any.
+	Integration string
+	// Steps defaults to activitySucceeds().
+	Steps []activityStep
+}
+
+type simulatedActivity struct {
+	activitySpec
+	id           string
+	environments []string
+	createdAt    time.Time
+	step         int
+}
+
+var envPathPattern = regexp.MustCompile(`/environments/([^/]+)`)
+
+var (
+	activityListPattern = regexp.MustCompile(`^/projects/[^/]+(?:/environments/([^/]+))?/activities$`)
+	activityPattern     = regexp.MustCompile(`^/projects/[^/]+/activities/([^/]+)(/log)?$`)
+
+	integrationActivityListPattern = regexp.MustCompile(`^/projects/[^/]+/integrations/([^/]+)/activities$`)
+)
+
+type activityTrigger struct {
+	method  string
+	pattern *regexp.Regexp
+	spec    activitySpec
+}
+
+// activitySimulator is an http.Handler middleware which serves activities
+// that change state as the CLI polls them.
+//
+// An activity moves to its next step each time it is fetched: on its own, in a
+// list of activities, or through its log. The log is streamed as JSON lines,
+// ending with a "seal" once the activity reaches its last step.
+type activitySimulator struct {
+	next      http.Handler
+	projectID string
+
+	mu         sync.Mutex
+	triggers   []activityTrigger
+	activities []*simulatedActivity
+	onChange   []func(activity map[string]any)
+}
+
+func newActivitySimulator(next http.Handler, projectID string) *activitySimulator {
+	return &activitySimulator{next: next, projectID: projectID}
+}
+
+// On makes requests matching a method and a path pattern (a regular
+// expression) start an activity, which is added to the response's embedded
+// activities, as the API does for operations such as redeploying.
+func (sim *activitySimulator) On(method, path string, spec activitySpec) {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	sim.triggers = append(sim.triggers, activityTrigger{method: method, pattern: regexp.MustCompile(path), spec: spec})
+}
+
+// OnChange registers a function which is called with an activity (in its API
+// representation) each time its state changes, before the change is served.
+func (sim *activitySimulator) OnChange(fn func(activity map[string]any)) {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	sim.onChange = append(sim.onChange, fn)
+}
+
+// Start adds an activity on the given environments, and returns its ID.
+func (sim *activitySimulator) Start(spec activitySpec, environments ...string) string {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	return sim.start(spec, environments).id
+}
+
+func (sim *activitySimulator) start(spec activitySpec, environments []string) *simulatedActivity {
+	if len(spec.Steps) == 0 {
+		spec.Steps = activitySucceeds()
+	}
+	if spec.Payload == nil {
+		spec.Payload = map[string]any{}
+	}
+	if environments == nil {
+		environments = []string{}
+	}
+	a := &simulatedActivity{
+		activitySpec: spec,
+		id:           "sim" + strconv.Itoa(len(sim.activities)+1),
+		environments: environments,
+		createdAt:    time.Now().UTC().Truncate(time.Second),
+	}
+	sim.activities = append(sim.activities, a)
+	return a
+}
+
+// State returns the current state of an activity, without advancing it.
+func (sim *activitySimulator) State(id string) string {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	if a := sim.find(id); a != nil {
+		return a.Steps[a.step].State
+	}
+	return ""
+}
+
+// IDs returns the IDs of the activities started so far.
+func (sim *activitySimulator) IDs() []string {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	ids := make([]string, len(sim.activities))
+	for i, a := range sim.activities {
+		ids[i] = a.id
+	}
+	return ids
+}
+
+func (sim *activitySimulator) find(id string) *simulatedActivity {
+	for _, a := range sim.activities {
+		if a.id == id {
+			return a
+		}
+	}
+	return nil
+}
+
+func (sim *activitySimulator) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodGet {
+		if m := activityPattern.FindStringSubmatch(req.URL.Path); m != nil {
+			sim.mu.Lock()
+			a := sim.find(m[1])
+			sim.mu.Unlock()
+			if a != nil {
+				if m[2] != "" {
+					sim.serveLog(w, a)
+				} else {
+					sim.serveActivity(w, a)
+				}
+				return
+			}
+		}
+		if m := activityListPattern.FindStringSubmatch(req.URL.Path); m != nil {
+			sim.serveEnvList(w, req, m[1])
+			return
+		}
+		if m := integrationActivityListPattern.FindStringSubmatch(req.URL.Path); m != nil {
+			id, _ := url.PathUnescape(m[1])
+			sim.serveList(w, nil, func(a *simulatedActivity) bool {
+				return a.Integration == id
+			})
+			return
+		}
+	}
+
+	sim.mu.Lock()
+	var trigger *activityTrigger
+	for i, t := range sim.triggers {
+		if t.method == req.Method && t.pattern.MatchString(req.URL.Path) {
+			trigger = &sim.triggers[i]
+			break
+		}
+	}
+	sim.mu.Unlock()
+	if trigger == nil {
+		sim.next.ServeHTTP(w, req)
+		return
+	}
+	sim.serveTrigger(w, req, trigger)
+}
+
+// serveTrigger passes the request on, and adds a new activity to the response.
+// If the mock API does not implement the operation, an empty 202 Accepted
+// response is used instead.
+func (sim *activitySimulator) serveTrigger(w http.ResponseWriter, req *http.Request, trigger *activityTrigger) {
+	rec := httptest.NewRecorder()
+	sim.next.ServeHTTP(rec, req)
+
+	status := rec.Code
+	body := map[string]any{}
+	switch status {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		status = http.StatusAccepted
+	default:
+		if status >= 400 {
+			copyResponse(w, rec)
+			return
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+	}
+
+	var environments []string
+	if m := envPathPattern.FindStringSubmatch(req.URL.Path); m != nil {
+		env, _ := url.PathUnescape(m[1])
+		environments = []string{env}
+	}
+
+	sim.mu.Lock()
+	a := sim.start(trigger.spec, environments)
+	data := sim.data(a)
+	sim.mu.Unlock()
+
+	embedded, _ := body["_embedded"].(map[string]any)
+	if embedded == nil {
+		embedded = map[string]any{}
+	}
+	activities, _ := embedded["activities"].([]any)
+	embedded["activities"] = append(activities, data)
+	body["_embedded"] = embedded
+	writeJSON(w, status, body)
+}
+
+func (sim *activitySimulator) serveActivity(w http.ResponseWriter, a *simulatedActivity) {
+	sim.mu.Lock()
+	changed := sim.advance(a)
+	data := sim.data(a)
+	sim.mu.Unlock()
+	sim.notify(changed)
+	writeJSON(w, http.StatusOK, data)
+}
+
+// serveEnvList adds simulated activities to the list from the mock API, of
+// the project or of an environment.
+func (sim *activitySimulator) serveEnvList(w http.ResponseWriter, req *http.Request, env string) {
+	rec := httptest.NewRecorder()
+	sim.next.ServeHTTP(rec, req)
+	var list []any
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &list) != nil {
+		copyResponse(w, rec)
+		return
+	}
+
+	env, _ = url.PathUnescape(env)
+	sim.serveList(w, list, func(a *simulatedActivity) bool {
+		return env == "" || slices.Contains(a.environments, env)
+	})
+}
+
+// serveList lists the matching simulated activities, followed by others.
+func (sim *activitySimulator) serveList(w http.ResponseWriter, list []any, match func(a *simulatedActivity) bool) {
+	sim.mu.Lock()
+	simulated := []any{}
+	var changed []map[string]any
+	for _, a := range sim.activities {
+		if match(a) {
+			if data := sim.advance(a); data != nil {
+				changed = append(changed, data)
+			}
+			simulated = append(simulated, sim.data(a))
+		}
+	}
+	sim.mu.Unlock()
+	sim.notify(changed...)
+
+	// Activities are listed with the most recent first.
+	for i, j := 0, len(simulated)-1; i < j; i, j = i+1, j-1 {
+		simulated[i], simulated[j] = simulated[j], simulated[i]
+	}
+	writeJSON(w, http.StatusOK, append(simulated, list...))
+}
+
+// serveLog streams the log messages reached so far, as JSON lines.
+func (sim *activitySimulator) serveLog(w http.ResponseWriter, a *simulatedActivity) {
+	sim.mu.Lock()
+	changed := sim.advance(a)
+	type logItem struct {
+		ID   string         `json:"_id"`
+		Data map[string]any `json:"data"`
+	}
+	var items []logItem
+	for i := 0; i <= a.step; i++ {
+		for j, msg := range a.Steps[i].Log {
+			items = append(items, logItem{
+				ID:   a.id + "-" + strconv.Itoa(i) + "-" + strconv.Itoa(j),
+				Data: map[string]any{"message": msg + "\n", "timestamp": a.createdAt.Add(time.Duration(i) * time.Second).Format(time.RFC3339)},
+			})
+		}
+	}
+	sealed := a.done()
+	sim.mu.Unlock()
+	sim.notify(changed)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	for _, item := range items {
+		_ = enc.Encode(item)
+	}
+	if sealed {
+		_ = enc.Encode(map[string]any{"seal": true})
+	}
+}
+
+// data returns the API representation of an activity.
+func (sim *activitySimulator) data(a *simulatedActivity) map[string]any {
+	step := a.Steps[a.step]
+	self := "/projects/" + url.PathEscape(sim.projectID) + "/activities/" + url.PathEscape(a.id)
+	updated := a.createdAt.Add(time.Duration(a.step) * time.Second)
+	data := map[string]any{
+		"id":                 a.id,
+		"type":               a.Type,
+		"state":              step.State,
+		"result":             nil,
+		"completion_percent": step.CompletionPercent,
+		"project":            sim.projectID,
+		"environments":       a.environments,
+		"description":        a.Description,
+		"text":               tagPattern.ReplaceAllString(a.Description, ""),
+		"payload":            a.Payload,
+		"created_at":         a.createdAt.Format(time.RFC3339),
+		"updated_at":         updated.Format(time.RFC3339),
+		"started_at":         nil,
+		"completed_at":       nil,
+		"_links": map[string]any{
+			"self": map[string]any{"href": self},
+			"log":  map[string]any{"href": self + "/log"},
+		},
+	}
+	if a.Integration != "" {
+		data["integration"] = a.Integration
+	}
+	if step.State != "pending" {
+		data["started_at"] = a.createdAt.Format(time.RFC3339)
+	}
+	if a.done() {
+		data["result"] = step.Result
+		data["completed_at"] = updated.Format(time.RFC3339)
+	}
+	return data
+}
+
+var tagPattern = regexp.MustCompile(`</?[a-z_]+>`)
+
+// advance moves an activity to its next step, and returns its new API
+// representation if its state changed, or nil.
+func (sim *activitySimulator) advance(a *simulatedActivity) map[string]any {
+	if a.step == len(a.Steps)-1 {
+		return nil
+	}
+	a.step++
+	if a.Steps[a.step].State == a.Steps[a.step-1].State {
+		return nil
+	}
+	return sim.data(a)
+}
+
+// notify calls the OnChange functions for each changed activity, without
+// holding the lock.
+func (sim *activitySimulator) notify(changed ...map[string]any) {
+	sim.mu.Lock()
+	onChange := slices.Clone(sim.onChange)
+	sim.mu.Unlock()
+	for _, data := range changed {
+		if data == nil {
+			continue
+		}
+		for _, fn := range onChange {
+			fn(data)
+		}
+	}
+}
+
+func (a *simulatedActivity) done() bool {
+	state := a.Steps[a.step].State
+	return state == "complete" || state == "cancelled"
+}
+
+func copyResponse(w http.ResponseWriter, rec *httptest.ResponseRecorder) {
+	for k, v := range rec.Header() {
+		if k != "Content-Length" {
+			w.Header()[k] = v
+		}
+	}
+	w.WriteHeader(rec.Code)
+	_, _ = w.Write(rec.Body.Bytes())
+}
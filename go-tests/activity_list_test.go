@@ -10,6 +10,7 @@ import (
 )
 
 func TestActivityList(t *testing.T) {
+	t.Parallel()
 	authServer := mockapi.NewAuthServer(t)
 	defer authServer.Close()
 
@@ -65,26 +66,9 @@ func TestActivityList(t *testing.T) {
 
 	f := newCommandFactory(t, apiServer.URL, authServer.URL)
 
-	f.Run("cc")
+	f.RunGolden("act-env", "act", "-p", projectID, "-e", ".")
 
-	assertTrimmed(t, `
-+------+---------------------------+--------------------------------------------------+----------+----------+---------+
-| ID   | Created                   | Description                                      | Progress | State    | Result  |
-+------+---------------------------+--------------------------------------------------+----------+----------+---------+
-| act1 | 2014-04-01T10:00:00+00:00 | Mock User created variable X on environment main | 100%     | complete | success |
-+------+---------------------------+--------------------------------------------------+----------+----------+---------+
-`, f.Run("act", "-p", projectID, "-e", "."))
-
-	assertTrimmed(t, `
-+------+----------------------+---------------------------------+----------+----------+---------+----------------+
-| ID   | Created              | Description                     | Progress | State    | Result  | Environment(s) |
-+------+----------------------+---------------------------------+----------+----------+---------+----------------+
-| act1 | 2014-04-01T10:00:00+ | Mock User created variable X on | 100%     | complete | success | main           |
-|      | 00:00                | environment main                |          |          |         |                |
-| act2 | 2014-04-01T09:00:00+ | Mock User created variable X    | 100%     | complete | success |                |
-|      | 00:00                |                                 |          |          |         |                |
-+------+----------------------+---------------------------------+----------+----------+---------+----------------+
-`, f.Run("act", "-p", projectID, "--all", "--limit", "20"))
+	f.RunGolden("act-all", "act", "-p", projectID, "--all", "--limit", "20")
 
 	assertTrimmed(t, "complete", f.Run("act:get", "-p", projectID, "-e", ".", "act1", "-P", "state"))
 	assertTrimmed(t, "2014-04-01T10:00:00+00:00", f.Run("act:get", "-p", projectID, "-e", ".", "act1", "-P", "created_at"))
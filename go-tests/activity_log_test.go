@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActivityLogStream(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil, envActivities).
+		WithActivitySimulator()
+	f, p := s.Factory(), s.ProjectID
+
+	id := s.Sim.Start(activitySpec{
+		Type:        "environment.push",
+		Description: "<user>Mock User</user> pushed to <environment>main</environment>",
+		Steps: []activityStep{
+			{State: "pending"},
+			{State: "in_progress", CompletionPercent: 10, Log: []string{"Building application 'app'"}},
+			{State: "in_progress", CompletionPercent: 60, Log: []string{"Deploying applications"}},
+			{State: "complete", Result: "success", CompletionPercent: 100, Log: []string{"Done"}},
+		},
+	}, "main")
+
+	// The log of an activity in progress is streamed until it completes.
+	stdOut, stdErr, err := f.RunCombinedOutput("act:log", id, "-p", p)
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "Activity ID: "+id)
+	assert.Contains(t, stdErr, "State: in progress")
+	assert.Equal(t, "Building application 'app'\nDeploying applications\nDone\n", stdOut)
+	assert.Equal(t, "complete", s.Sim.State(id))
+
+	// The log of a complete activity is read once.
+	s.Recorder.Reset()
+	stdOut, _, err = f.RunCombinedOutput("act:log", id, "-p", p)
+	assert.NoError(t, err)
+	assert.Equal(t, "Building application 'app'\nDeploying applications\nDone\n", stdOut)
+	s.Recorder.AssertCount(t, 1, "GET", "/activities/"+id+"/log")
+}
+
+func TestActivityLogRefetchDoesNotDuplicate(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil, envActivities).
+		WithActivitySimulator()
+	f, p := s.Factory(), s.ProjectID
+
+	id := s.Sim.Start(activitySpec{
+		Type:        "environment.push",
+		Description: "<user>Mock User</user> pushed to <environment>main</environment>",
+		Steps: []activityStep{
+			{State: "in_progress", CompletionPercent: 10, Log: []string{"Building application 'app'"}},
+			{State: "in_progress", CompletionPercent: 40, Log: []string{"Building application 'worker'"}},
+			{State: "in_progress", CompletionPercent: 70, Log: []string{"Deploying applications"}},
+			{State: "complete", Result: "success", CompletionPercent: 100, Log: []string{"Done"}},
+		},
+	}, "main")
+
+	// Each fetch of an unsealed log ends early, so the CLI fetches it again,
+	// receiving the items it has already seen. These are printed only once.
+	stdOut, _, err := f.RunCombinedOutput("act:log", id, "-p", p)
+	assert.NoError(t, err)
+	assert.Greater(t, len(s.Recorder.Find("GET", "/activities/"+id+"/log")), 1)
+	assert.Equal(t, "Building application 'app'\nBuilding application 'worker'\nDeploying applications\nDone\n", stdOut)
+}
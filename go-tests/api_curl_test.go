@@ -14,6 +14,7 @@ import (
 )
 
 func TestApiCurlCommand(t *testing.T) {
+	t.Parallel()
 	validToken := "valid-token"
 
 	mux := chi.NewMux()
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"net/http"
+	"os/exec"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/platformsh/cli/pkg/mockapi"
+)
+
+// setupFaultTest creates a project with an environment and an activity, served
+// by a mock API behind a fault injector.
+func setupFaultTest(t *testing.T) (*scenario, *cmdFactory, *faultInjector) {
+	var faults *faultInjector
+	s := newScenario(t).
+		WithOrg("org-id-1", "org-1", "Org 1").
+		WithEnv("main", "production", "active", nil, envActivities).
+		Use(func(next http.Handler) http.Handler {
+			faults = newFaultInjector(next, 1)
+			return faults
+		})
+
+	created, _ := time.Parse(time.RFC3339, "2014-04-01T10:00:00Z")
+	s.Handler.SetProjectActivities(s.ProjectID, []*mockapi.Activity{{
+		ID:                "act1",
+		Type:              "environment.push",
+		State:             "complete",
+		Result:            "success",
+		CompletionPercent: 100,
+		Project:           s.ProjectID,
+		Environments:      []string{"main"},
+		Description:       "<user>Mock User</user> pushed to <environment>main</environment>",
+		Text:              "Mock User pushed to main",
+		CreatedAt:         created,
+		UpdatedAt:         created,
+	}})
+
+	f := s.Factory()
+
+	return s, f, faults
+}
+
+func assertExitCode(t *testing.T, expected int, err error) {
+	t.Helper()
+	var exitErr *exec.ExitError
+	if assert.ErrorAs(t, err, &exitErr) {
+		assert.Equal(t, expected, exitErr.ExitCode())
+	}
+}
+
+func TestProjectListFaults(t *testing.T) {
+	t.Parallel()
+	s, f, faults := setupFaultTest(t)
+	projectID := s.ProjectID
+
+	// Guzzle uses the HTTP status as the exception code, which is capped to 255 as an exit code.
+	for _, status := range []int{500, 502, 503} {
+		faults.Add(fault{Method: "GET", Path: `/extended-access$`, Status: status, Times: 1})
+		_, stdErr, err := f.RunCombinedOutput("pro", "--refresh", "1")
+		assertExitCode(t, 255, err)
+		assert.Contains(t, stdErr, strconv.Itoa(status))
+	}
+
+	// The CLI does not retry rate-limited requests, even with Retry-After.
+	s.Recorder.Reset()
+	faults.Add(fault{Method: "GET", Path: `/extended-access$`, Status: 429, RetryAfter: "1"})
+	_, stdErr, err := f.RunCombinedOutput("pro", "--refresh", "1")
+	assertExitCode(t, 255, err)
+	assert.Contains(t, stdErr, "429")
+	assert.Contains(t, stdErr, "Too Many Requests")
+	s.Recorder.AssertCount(t, 1, "GET", "/extended-access")
+
+	faults.Clear()
+	faults.Add(fault{Method: "GET", Path: `/extended-access$`, Status: 403})
+	_, stdErr, err = f.RunCombinedOutput("pro", "--refresh", "1")
+	assertExitCode(t, 6, err)
+	assert.Contains(t, stdErr, "Permission denied.")
+
+	// Slow responses are fine.
+	faults.Clear()
+	faults.Add(fault{Path: `/extended-access$`, Delay: 500 * time.Millisecond})
+	assert.Equal(t, projectID+"\n", f.Run("pro", "--refresh", "1", "--pipe"))
+}
+
+func TestEnvironmentListFaults(t *testing.T) {
+	t.Parallel()
+	s, f, faults := setupFaultTest(t)
+	projectID := s.ProjectID
+
+	faults.Add(fault{Method: "GET", Path: `/environments$`, Status: 502, Times: 1})
+	_, stdErr, err := f.RunCombinedOutput("env:list", "-p", projectID, "--pipe")
+	assertExitCode(t, 255, err)
+	assert.Contains(t, stdErr, "502")
+	assert.Contains(t, stdErr, "Bad Gateway")
+
+	// The fault only applied to the first request.
+	assert.Equal(t, "main\n", f.Run("env:list", "-p", projectID, "--pipe"))
+
+	faults.Add(fault{Method: "GET", Path: `/environments$`, Truncate: true, Times: 1})
+	_, stdErr, err = f.RunCombinedOutput("env:list", "-p", projectID, "--pipe")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "JSON")
+
+	faults.Add(fault{Method: "GET", Path: `/environments$`, DropConnection: true, Times: 1})
+	_, stdErr, err = f.RunCombinedOutput("env:list", "-p", projectID, "--pipe")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "cURL error")
+
+	assert.Equal(t, "main\n", f.Run("env:list", "-p", projectID, "--pipe"))
+}
+
+func TestActivityLogFaults(t *testing.T) {
+	t.Parallel()
+	s, f, faults := setupFaultTest(t)
+	projectID := s.ProjectID
+
+	faults.Add(fault{Method: "GET", Path: `/activities/act1$`, Status: 500})
+	_, stdErr, err := f.RunCombinedOutput("act:log", "-p", projectID, "-e", "main", "act1")
+	assertExitCode(t, 255, err)
+	assert.Contains(t, stdErr, "500")
+	assert.Contains(t, stdErr, "Internal Server Error")
+	assert.NotContains(t, stdErr, "Exception trace")
+
+	// Half of requests fail, repeatably thanks to the seed. Retry until one
+	// attempt succeeds, to show that failures do not leave a bad cache behind.
+	faults.Clear()
+	faults.Add(fault{Method: "GET", Path: `/activities/act1$`, Status: 503, Probability: 0.5})
+	var succeeded bool
+	for range 10 {
+		if _, _, err := f.RunCombinedOutput("act:get", "-p", projectID, "-e", "main", "act1", "-P", "state"); err == nil {
+			succeeded = true
+			break
+		}
+	}
+	require.True(t, succeeded)
+	faults.Clear()
+	assertTrimmed(t, "complete", f.Run("act:get", "-p", projectID, "-e", "main", "act1", "-P", "state"))
+}
@@ -13,6 +13,7 @@ import (
 )
 
 func TestAppConfig(t *testing.T) {
+	t.Parallel()
 	authServer := mockapi.NewAuthServer(t)
 	defer authServer.Close()
 
@@ -55,6 +56,7 @@ mounts: {  }
 }
 
 func TestAppConfigLocal(t *testing.T) {
+	t.Parallel()
 	f := cmdWithLocalApp(t, &mockapi.App{
 		Name: "local-app",
 		Type: "golang:1.24",
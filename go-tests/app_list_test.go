@@ -1,7 +1,6 @@
 package tests
 
 import (
-	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -11,49 +10,18 @@ import (
 )
 
 func TestAppList(t *testing.T) {
-	authServer := mockapi.NewAuthServer(t)
-	defer authServer.Close()
-
-	apiHandler := mockapi.NewHandler(t)
-
-	apiServer := httptest.NewServer(apiHandler)
-	defer apiServer.Close()
-
-	projectID := mockapi.ProjectID()
-
-	apiHandler.SetProjects([]*mockapi.Project{{
-		ID: projectID,
-		Links: mockapi.MakeHALLinks("self=/projects/"+projectID,
-			"environments=/projects/"+projectID+"/environments"),
-		DefaultBranch: "main",
-	}})
-
-	main := makeEnv(projectID, "main", "production", "active", nil)
-	main.SetCurrentDeployment(&mockapi.Deployment{
-		WebApps: map[string]mockapi.App{
-			"app": {Name: "app", Type: "golang:1.23", Size: "AUTO"},
-		},
-		Services: map[string]mockapi.App{},
-		Routes:   map[string]any{},
-		Workers: map[string]mockapi.Worker{
-			"app--worker1": {
-				App:    mockapi.App{Name: "app--worker1", Type: "golang:1.23", Size: "AUTO"},
-				Worker: mockapi.WorkerInfo{Commands: mockapi.Commands{Start: "sleep 60"}},
-			},
-		},
-		Links: mockapi.MakeHALLinks("self=/projects/" + projectID + "/environments/main/deployment/current"),
-	})
-
-	envs := []*mockapi.Environment{
-		main,
-		makeEnv(projectID, "staging", "staging", "active", "main"),
-		makeEnv(projectID, "dev", "development", "active", "staging"),
-		makeEnv(projectID, "fix", "development", "inactive", "dev"),
-	}
-
-	apiHandler.SetEnvironments(envs)
-
-	f := newCommandFactory(t, apiServer.URL, authServer.URL)
+	t.Parallel()
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil).
+		WithEnv("staging", "staging", "active", "main").
+		WithEnv("dev", "development", "active", "staging").
+		WithEnv("fix", "development", "inactive", "dev").
+		WithApp("main", mockapi.App{Name: "app", Type: "golang:1.23", Size: "AUTO"}).
+		WithWorker("main", mockapi.Worker{
+			App:    mockapi.App{Name: "app--worker1", Type: "golang:1.23", Size: "AUTO"},
+			Worker: mockapi.WorkerInfo{Commands: mockapi.Commands{Start: "sleep 60"}},
+		})
+	f, projectID := s.Factory(), s.ProjectID
 
 	assertTrimmed(t, `
 Name	Type
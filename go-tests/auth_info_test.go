@@ -2,15 +2,17 @@ package tests
 
 import (
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/platformsh/cli/pkg/mockapi"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAuthInfo(t *testing.T) {
-	authServer := mockapi.NewAuthServer(t)
-	defer authServer.Close()
+	t.Parallel()
+	authServer, authRecorder := recordServer(t, mockapi.NewAuthServer(t))
 
 	apiHandler := mockapi.NewHandler(t)
 	apiHandler.SetMyUser(&mockapi.User{
@@ -47,4 +49,14 @@ func TestAuthInfo(t *testing.T) {
 `, f.Run("auth:info", "-v", "--refresh"))
 
 	assert.Equal(t, "my-user-id\n", f.Run("auth:info", "-P", "id"))
+
+	// Token requests should exchange the configured API token.
+	tokenRequests := authRecorder.Find("POST", "/oauth2/token")
+	require.NotEmpty(t, tokenRequests)
+	for _, r := range tokenRequests {
+		form, err := url.ParseQuery(string(r.Body))
+		require.NoError(t, err)
+		assert.Equal(t, "api_token", form.Get("grant_type"))
+		assert.Equal(t, mockapi.ValidAPITokens[0], form.Get("api_token"))
+	}
 }
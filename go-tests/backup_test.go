@@ -1,7 +1,6 @@
 package tests
 
 import (
-	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -12,35 +11,16 @@ import (
 )
 
 func TestBackupList(t *testing.T) {
-	authServer := mockapi.NewAuthServer(t)
-	defer authServer.Close()
-
-	apiHandler := mockapi.NewHandler(t)
-	apiServer := httptest.NewServer(apiHandler)
-	defer apiServer.Close()
-
-	projectID := mockapi.ProjectID()
-
-	apiHandler.SetProjects([]*mockapi.Project{
-		{
-			ID:            projectID,
-			DefaultBranch: "main",
-			Links: mockapi.MakeHALLinks(
-				"self=/projects/"+projectID,
-				"environments=/projects/"+projectID+"/environments",
-			),
-		},
-	})
-	main := makeEnv(projectID, "main", "production", "active", nil)
-	main.Links["backups"] = mockapi.HALLink{HREF: "/projects/" + projectID + "//environments/main/backups"}
-	apiHandler.SetEnvironments([]*mockapi.Environment{main})
+	t.Parallel()
+	s := newScenario(t).WithEnv("main", "production", "active", nil, envBackups)
+	projectID := s.ProjectID
 
 	created1, err := time.Parse(time.RFC3339, "2014-04-01T10:00:00+01:00")
 	require.NoError(t, err)
 	created2, err := time.Parse(time.RFC3339, "2015-04-01T10:00:00+01:00")
 	require.NoError(t, err)
 
-	apiHandler.SetProjectBackups(projectID, []*mockapi.Backup{
+	s.Handler.SetProjectBackups(projectID, []*mockapi.Backup{
 		{
 			ID:            "123",
 			EnvironmentID: "main",
@@ -63,7 +43,7 @@ func TestBackupList(t *testing.T) {
 		},
 	})
 
-	f := newCommandFactory(t, apiServer.URL, authServer.URL)
+	f := s.Factory()
 
 	assertTrimmed(t, `
 +---------------------------+-----------+------------+
@@ -85,33 +65,212 @@ func TestBackupList(t *testing.T) {
 }
 
 func TestBackupCreate(t *testing.T) {
-	authServer := mockapi.NewAuthServer(t)
-	defer authServer.Close()
+	t.Parallel()
+	s := newScenario(t).WithEnv("main", "production", "active", nil, envBackups)
+	f, projectID := s.Factory(), s.ProjectID
+
+	f.Run("backup", "-p", projectID, "-e", ".")
+
+	assert.NotEmpty(t, f.Run("backups", "-p", projectID, "-e", "."))
+}
+
+func TestBackupCreateWait(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil, envBackups).
+		WithEnv("dev", "development", "active", "main", envBackups).
+		WithActivitySimulator()
+	s.Sim.On("POST", `/environments/main/backups$`, activitySpec{
+		Type:        "environment.backup",
+		Description: "<user>Mock User</user> created a backup of <environment>main</environment>",
+		Payload:     map[string]any{"backup_name": "abcdefghijklmnop"},
+		Steps:       activitySucceeds("Creating backup"),
+	})
+	s.Sim.On("POST", `/environments/dev/backups$`, activitySpec{
+		Type:        "environment.backup",
+		Description: "<user>Mock User</user> created a backup of <environment>dev</environment>",
+		Steps:       activityFails("Creating backup", "Failed to snapshot the database"),
+	})
+	f, p := s.Factory(), s.ProjectID
+
+	stdOut, stdErr, err := f.RunCombinedOutput("backup", "-p", p, "-e", "main")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "Creating backup")
+	assert.Contains(t, stdErr, "The activity succeeded: [sim1] Mock User created a backup of main")
+	assert.Contains(t, stdOut, "Backup name: abcdefghijklmnop")
+	assert.Equal(t, "complete", s.Sim.State("sim1"))
 
-	apiHandler := mockapi.NewHandler(t)
-	apiServer := httptest.NewServer(apiHandler)
-	defer apiServer.Close()
+	stdOut, stdErr, err = f.RunCombinedOutput("backup", "-p", p, "-e", "dev")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Failed to snapshot the database")
+	assert.Contains(t, stdErr, "The activity failed: [sim2] Mock User created a backup of dev")
+	assert.NotContains(t, stdOut, "Backup name:")
+}
 
-	projectID := mockapi.ProjectID()
+func TestBackupRestoreInteractive(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil, envBackups).
+		WithEnv("staging", "staging", "active", "main")
+	projectID := s.ProjectID
 
-	apiHandler.SetProjects([]*mockapi.Project{
+	created, err := time.Parse(time.RFC3339, "2014-04-01T10:00:00+01:00")
+	require.NoError(t, err)
+	s.Handler.SetProjectBackups(projectID, []*mockapi.Backup{
 		{
-			ID:            projectID,
-			DefaultBranch: "main",
-			Links: mockapi.MakeHALLinks(
-				"self=/projects/"+projectID,
-				"environments=/projects/"+projectID+"/environments",
-			),
+			ID:            "123",
+			EnvironmentID: "main",
+			Status:        "CREATED",
+			Restorable:    true,
+			CommitID:      "foo",
+			CreatedAt:     created,
 		},
 	})
-	main := makeEnv(projectID, "main", "production", "active", nil)
-	main.Links["backups"] = mockapi.HALLink{HREF: "/projects/" + projectID + "/environments/main//backups"}
-	main.Links["#backup"] = mockapi.HALLink{HREF: "/projects/" + projectID + "/environments/main/backups"}
-	apiHandler.SetEnvironments([]*mockapi.Environment{main})
 
-	f := newCommandFactory(t, apiServer.URL, authServer.URL)
+	f := s.Factory()
 
-	f.Run("backup", "-p", projectID, "-e", ".")
+	session := f.RunInteractive("backup:restore", "-p", projectID, "-e", "main", "123")
+	session.Expect("Backup ID: 123")
+	session.Expect("Are you sure you want to restore this backup? [Y/n]")
+	session.SendLine("n")
+	assert.Error(t, session.Wait())
+	assert.NotContains(t, session.Transcript(), "Restoring backup")
 
-	assert.NotEmpty(t, f.Run("backups", "-p", projectID, "-e", "."))
+	session = f.RunInteractive("backup:restore", "-p", projectID, "-e", "main", "123", "--target", "staging")
+	session.Expect("Original environment:")
+	session.Expect("Are you sure you want to restore this backup to the environment")
+	session.SendLine("n")
+	assert.Error(t, session.Wait())
+	assert.NotContains(t, session.Transcript(), "Restoring backup")
+}
+
+// setupBackupTest creates a scenario with backups on the main environment,
+// served by the backups stand-in, and the activity simulator.
+func setupBackupTest(t *testing.T) *scenario {
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil, envBackups).
+		WithActivitySimulator()
+	created1, err := time.Parse(time.RFC3339, "2014-04-01T10:00:00Z")
+	require.NoError(t, err)
+	created2, err := time.Parse(time.RFC3339, "2015-04-01T10:00:00Z")
+	require.NoError(t, err)
+	created3, err := time.Parse(time.RFC3339, "2013-04-01T10:00:00Z")
+	require.NoError(t, err)
+	return s.WithBackups(
+		&mockapi.Backup{ID: "123", EnvironmentID: "main", Restorable: true, Safe: true, CommitID: "foo", CreatedAt: created1},
+		&mockapi.Backup{ID: "456", EnvironmentID: "main", Restorable: true, Automated: true, CommitID: "bar", CreatedAt: created2},
+		&mockapi.Backup{ID: "789", EnvironmentID: "main", Restorable: false, CommitID: "baz", CreatedAt: created3},
+	)
+}
+
+func TestBackupGet(t *testing.T) {
+	t.Parallel()
+	s := setupBackupTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	assertTrimmed(t, "foo", f.Run("backup:get", "-p", p, "-e", "main", "123", "-P", "commit_id"))
+	assertTrimmed(t, "true", f.Run("backup:get", "-p", p, "-e", "main", "123", "-P", "safe"))
+	assertTrimmed(t, "CREATED", f.Run("backup:get", "-p", p, "-e", "main", "123", "-P", "status"))
+	assertTrimmed(t, "2014-04-01T10:00:00+00:00", f.Run("backup:get", "-p", p, "-e", "main", "123", "-P", "created_at"))
+	assertTrimmed(t, "false", f.Run("backup:get", "-p", p, "-e", "main", "789", "-P", "restorable"))
+
+	// The most recent backup is the default.
+	assertTrimmed(t, "456", f.Run("backup:get", "-p", p, "-e", "main", "-P", "id"))
+
+	table := f.Run("backup:get", "-p", p, "-e", "main", "456")
+	assert.Contains(t, table, "| automated")
+	assert.Contains(t, table, "| commit_id")
+	assert.NotContains(t, table, "_links")
+
+	_, stdErr, err := f.RunCombinedOutput("backup:get", "-p", p, "-e", "main", "999")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Backup not found: 999")
+}
+
+func TestBackupDelete(t *testing.T) {
+	t.Parallel()
+	s := setupBackupTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	// Declining the confirmation keeps the backup.
+	session := f.RunInteractive("backup:delete", "-p", p, "-e", "main", "123")
+	session.Expect("Are you sure you want to delete the backup 123 (2014-04-01T10:00:00+00:00)? [Y/n]")
+	session.SendLine("n")
+	assert.Error(t, session.Wait())
+	s.Recorder.AssertNone(t, "DELETE", "/backups/123")
+	assert.NotNil(t, s.Backup("123"))
+
+	_, stdErr, err := f.RunCombinedOutput("backup:delete", "-p", p, "-e", "main", "123")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Are you sure you want to delete the backup 123 (2014-04-01T10:00:00+00:00)? [Y/n] y")
+	assert.Contains(t, stdErr, "The backup 123 (2014-04-01T10:00:00+00:00) has been deleted.")
+	s.Recorder.AssertCount(t, 1, "DELETE", "/projects/"+p+"/environments/main/backups/123")
+	assert.Nil(t, s.Backup("123"))
+	assert.Equal(t, "ID\n456\n789\n", f.Run("backups", "-p", p, "-e", "main", "--format", "plain", "--columns", "id"))
+
+	_, stdErr, err = f.RunCombinedOutput("backup:delete", "-p", p, "-e", "main", "123")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Backup not found: 123")
+
+	_, stdErr, err = f.RunCombinedOutput("backup:delete", "-p", p, "-e", "main")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "A backup ID is required in non-interactive mode.")
+	s.Recorder.AssertCount(t, 1, "DELETE", "/backups/123")
+	s.Recorder.AssertNone(t, "DELETE", "/backups/456")
+}
+
+func TestBackupRestore(t *testing.T) {
+	t.Parallel()
+	s := setupBackupTest(t)
+	s.Sim.On("POST", `/backups/123/restore$`, activitySpec{
+		Type:        "environment.restore",
+		Description: "<user>Mock User</user> restored <environment>main</environment> from backup <backup>123</backup>",
+		Steps:       activitySucceeds("Restoring backup 123", "  Restoring the database"),
+	})
+	s.Sim.On("POST", `/backups/456/restore$`, activitySpec{
+		Type:        "environment.restore",
+		Description: "<user>Mock User</user> restored <environment>main</environment> from backup <backup>456</backup>",
+		Steps:       activityFails("Restoring backup 456", "  E: Failed to restore the database"),
+	})
+	f, p := s.Factory(), s.ProjectID
+
+	_, stdErr, err := f.RunCombinedOutput("backup:restore", "-p", p, "-e", "main", "123")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "Backup ID: 123")
+	assert.Contains(t, stdErr, "Are you sure you want to restore this backup? [Y/n] y")
+	assert.NotContains(t, stdErr, "Original environment:")
+	assert.Contains(t, stdErr, "Restoring backup 123 to main")
+	assert.Contains(t, stdErr, "Restoring backup 123\n  Restoring the database\n")
+	assert.Contains(t, stdErr, "The activity succeeded: [sim1] Mock User restored main from backup 123")
+	assert.Equal(t, "complete", s.Sim.State("sim1"))
+	body := s.Recorder.RequireOne(t, "POST", "/projects/"+p+"/environments/main/backups/123/restore").JSON(t)
+	assert.Equal(t, "main", body["environment_name"])
+
+	// The most recent backup is restored by default, and a failed restore
+	// activity is an error.
+	_, stdErr, err = f.RunCombinedOutput("backup:restore", "-p", p, "-e", "main")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Finding the most recent backup for the environment")
+	assert.Contains(t, stdErr, "Backup ID: 456")
+	assert.Contains(t, stdErr, "E: Failed to restore the database")
+	assert.Contains(t, stdErr, "The activity failed: [sim2] Mock User restored main from backup 456")
+	assert.Equal(t, "complete", s.Sim.State("sim2"))
+
+	// The activity is not polled with --no-wait.
+	s.Recorder.Reset()
+	_, stdErr, err = f.RunCombinedOutput("backup:restore", "-p", p, "-e", "main", "123", "--no-wait")
+	assert.NoError(t, err)
+	assert.NotContains(t, stdErr, "Waiting for the activity")
+	assert.Equal(t, "pending", s.Sim.State("sim3"))
+	s.Recorder.AssertNone(t, "GET", "/activities/sim3")
+
+	_, stdErr, err = f.RunCombinedOutput("backup:restore", "-p", p, "-e", "main", "789")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The backup 789 cannot be restored")
+	s.Recorder.AssertNone(t, "POST", "/backups/789/restore")
+
+	_, stdErr, err = f.RunCombinedOutput("backup:restore", "-p", p, "-e", "main", "999")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Backup not found: 999")
+	assert.Len(t, s.Sim.IDs(), 3)
 }
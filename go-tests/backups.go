@@ -0,0 +1,179 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/platformsh/cli/pkg/mockapi"
+)
+
+// backupsAPI stands in for the environment backups API.
+type backupsAPI struct {
+	s *scenario
+
+	mu      sync.Mutex
+	backups []*mockapi.Backup
+}
+
+// WithBackups serves environment backups from a backupsAPI, instead of the
+// mock API, so that backups have links to restore and delete them.
+// Environments need the envBackups capability.
+//
+// Restoring and deleting backups returns no activities: tests can start them
+// with the activity simulator (see WithActivitySimulator), which must be added
+// first.
+func (s *scenario) WithBackups(backups ...*mockapi.Backup) *scenario {
+	if s.backups == nil {
+		s.backups = &backupsAPI{s: s}
+		s.Use(standIn(s.backups.routes))
+	}
+	api := s.backups
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	for _, b := range backups {
+		if b.Status == "" {
+			b.Status = "CREATED"
+		}
+		if b.CreatedAt.IsZero() {
+			b.CreatedAt, _ = time.Parse(time.RFC3339, "2014-04-01T10:00:00Z")
+		}
+		api.backups = append(api.backups, b)
+	}
+	return s
+}
+
+// Backup returns a backup by ID, or nil if it does not exist (or was deleted).
+func (s *scenario) Backup(id string) *mockapi.Backup {
+	s.backups.mu.Lock()
+	defer s.backups.mu.Unlock()
+	for _, b := range s.backups.backups {
+		if b.ID == id {
+			return b
+		}
+	}
+	return nil
+}
+
+func (api *backupsAPI) routes(r chi.Router) {
+	base := "/projects/{project}/environments/{environment}/backups"
+	r.Get(base, api.handleList)
+	r.Get(base+"/{id}", api.handleGet)
+	r.Delete(base+"/{id}", api.handleDelete)
+	r.Post(base+"/{id}/restore", api.handleRestore)
+}
+
+// find finds a backup of the environment in the request's path.
+func (api *backupsAPI) find(req *http.Request) *mockapi.Backup {
+	for _, b := range api.backups {
+		if b.EnvironmentID == chi.URLParam(req, "environment") && b.ID == chi.URLParam(req, "id") {
+			return b
+		}
+	}
+	return nil
+}
+
+// handleList lists an environment's backups, the most recent first.
+func (api *backupsAPI) handleList(w http.ResponseWriter, req *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	var backups []*mockapi.Backup
+	for _, b := range api.backups {
+		if b.EnvironmentID == chi.URLParam(req, "environment") {
+			backups = append(backups, b)
+		}
+	}
+	slices.SortStableFunc(backups, func(a, b *mockapi.Backup) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	items := make([]any, 0, len(backups))
+	for _, b := range backups {
+		items = append(items, api.backupData(b))
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (api *backupsAPI) handleGet(w http.ResponseWriter, req *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	b := api.find(req)
+	if b == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Backup not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, api.backupData(b))
+}
+
+func (api *backupsAPI) handleDelete(w http.ResponseWriter, req *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	b := api.find(req)
+	if b == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Backup not found"})
+		return
+	}
+	api.backups = slices.DeleteFunc(api.backups, func(other *mockapi.Backup) bool {
+		return other == b
+	})
+	writeJSON(w, http.StatusAccepted, map[string]any{"_embedded": map[string]any{"activities": []any{}}})
+}
+
+// handleRestore accepts a request to restore a backup to an existing
+// environment, or to a new one if "branch_from" is set.
+func (api *backupsAPI) handleRestore(w http.ResponseWriter, req *http.Request) {
+	var params struct {
+		EnvironmentName string `json:"environment_name"`
+		BranchFrom      string `json:"branch_from"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": "Invalid restore parameters"})
+		return
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	b := api.find(req)
+	if b == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Backup not found"})
+		return
+	}
+	if !b.Restorable {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": "The backup cannot be restored"})
+		return
+	}
+	target := params.EnvironmentName
+	if target == "" {
+		target = b.EnvironmentID
+	}
+	if !api.s.hasEnv(target) && params.BranchFrom == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": "Environment not found: " + target})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"_embedded": map[string]any{"activities": []any{}}})
+}
+
+// backupData returns the API representation of a backup.
+func (api *backupsAPI) backupData(b *mockapi.Backup) map[string]any {
+	self := "/projects/" + url.PathEscape(api.s.ProjectID) + "/environments/" + url.PathEscape(b.EnvironmentID) +
+		"/backups/" + url.PathEscape(b.ID)
+	return map[string]any{
+		"id":          b.ID,
+		"environment": b.EnvironmentID,
+		"status":      b.Status,
+		"safe":        b.Safe,
+		"restorable":  b.Restorable,
+		"automated":   b.Automated,
+		"commit_id":   b.CommitID,
+		"created_at":  b.CreatedAt.Format(time.RFC3339),
+		"updated_at":  b.CreatedAt.Format(time.RFC3339),
+		"_links": map[string]any{
+			"self":     map[string]any{"href": self},
+			"#restore": map[string]any{"href": self + "/restore"},
+			"#delete":  map[string]any{"href": self},
+		},
+	}
+}
@@ -0,0 +1,240 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// addressProperties are the writable properties of a billing address.
+var addressProperties = []string{
+	"country", "name_line", "premise", "sub_premise", "thoroughfare",
+	"administrative_area", "sub_administrative_area", "locality", "dependent_locality", "postal_code",
+}
+
+// profileProperties are the writable properties of a billing profile.
+var profileProperties = []string{"company_name", "billing_contact", "vat_number", "default_catalog", "project_options_url"}
+
+// countryList reads the CLDR country list (code => name) which the CLI also
+// uses to validate countries.
+var countryList = sync.OnceValues(func() (map[string]string, error) {
+	b, err := os.ReadFile("../resources/cldr/countries.json")
+	if err != nil {
+		return nil, err
+	}
+	var countries map[string]string
+	err = json.Unmarshal(b, &countries)
+	return countries, err
+})
+
+// billingAPI stands in for the billing address and profile of
+// organizations.
+type billingAPI struct {
+	s         *scenario
+	countries map[string]string
+
+	mu        sync.Mutex
+	addresses map[string]map[string]any
+	profiles  map[string]map[string]any
+}
+
+// WithBillingAddress sets fields of an organization's billing address, and
+// serves billing data. Other fields are empty.
+func (s *scenario) WithBillingAddress(orgID string, fields map[string]string) *scenario {
+	api := s.serveBilling()
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	address, _ := api.billingData(orgID)
+	for k, v := range fields {
+		address[k] = v
+	}
+	return s
+}
+
+// WithBillingProfile sets fields of an organization's billing profile, and
+// serves billing data. Other fields are empty.
+func (s *scenario) WithBillingProfile(orgID string, fields map[string]string) *scenario {
+	api := s.serveBilling()
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	_, profile := api.billingData(orgID)
+	for k, v := range fields {
+		profile[k] = v
+	}
+	return s
+}
+
+// serveBilling serves the billing address and profile of organizations from
+// a billingAPI (once). Organizations get the "orders" link when the current
+// user can manage billing.
+func (s *scenario) serveBilling() *billingAPI {
+	if s.billing == nil {
+		countries, err := countryList()
+		require.NoError(s.t, err)
+		s.billing = &billingAPI{
+			s:         s,
+			countries: countries,
+			addresses: map[string]map[string]any{},
+			profiles:  map[string]map[string]any{},
+		}
+		s.Use(standIn(s.billing.routes))
+	}
+	return s.billing
+}
+
+func (api *billingAPI) routes(r chi.Router) {
+	r.Get("/organizations/{organization}/address", api.handleGetAddress)
+	r.Patch("/organizations/{organization}/address", api.handleUpdateAddress)
+	r.Get("/organizations/{organization}/profile", api.handleGetProfile)
+	r.Patch("/organizations/{organization}/profile", api.handleUpdateProfile)
+}
+
+// billingData returns an organization's billing address and profile,
+// creating empty ones if needed.
+func (api *billingAPI) billingData(orgID string) (address, profile map[string]any) {
+	if api.addresses[orgID] == nil {
+		address = map[string]any{}
+		for _, k := range addressProperties {
+			address[k] = ""
+		}
+		api.addresses[orgID] = address
+	}
+	if api.profiles[orgID] == nil {
+		profile = map[string]any{"id": orgID}
+		for _, k := range profileProperties {
+			profile[k] = ""
+		}
+		api.profiles[orgID] = profile
+	}
+	return api.addresses[orgID], api.profiles[orgID]
+}
+
+// canManageBilling checks if a user is an organization admin or has the
+// "billing" permission.
+func (s *scenario) canManageBilling(orgID, userID string) bool {
+	if s.isOrgAdmin(orgID, userID) {
+		return true
+	}
+	g := s.findOrgGrant(orgID, userID)
+	return g != nil && slices.Contains(g.Permissions, "billing")
+}
+
+// billingFromRequest returns the billing address and profile of the
+// organization in the request's URL, or writes an error response.
+func (api *billingAPI) billingFromRequest(w http.ResponseWriter, req *http.Request) (address, profile map[string]any) {
+	s, orgID := api.s, chi.URLParam(req, "organization")
+	s.mu.Lock()
+	found, allowed := s.findOrg(orgID) != nil, s.canManageBilling(orgID, s.MyUserID)
+	s.mu.Unlock()
+	if !found {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Organization not found"})
+		return nil, nil
+	}
+	if !allowed {
+		writeJSON(w, http.StatusForbidden, map[string]any{"message": "You do not have permission to manage billing for this organization."})
+		return nil, nil
+	}
+	return api.billingData(orgID)
+}
+
+func (api *billingAPI) handleGetAddress(w http.ResponseWriter, req *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if address, _ := api.billingFromRequest(w, req); address != nil {
+		writeJSON(w, http.StatusOK, billingResponse(req, address))
+	}
+}
+
+// handleUpdateAddress validates the country against the CLDR list.
+// Validation errors are keyed by property at the top level of the response.
+func (api *billingAPI) handleUpdateAddress(w http.ResponseWriter, req *http.Request) {
+	var params map[string]string
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"detail": "Invalid address parameters"})
+		return
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	address, _ := api.billingFromRequest(w, req)
+	if address == nil {
+		return
+	}
+	errs := map[string]string{}
+	for k, v := range params {
+		if !slices.Contains(addressProperties, k) {
+			errs[k] = "This field is not writable."
+		} else if _, ok := api.countries[v]; k == "country" && !ok {
+			errs[k] = "Invalid country code: " + v
+		}
+	}
+	if len(errs) > 0 {
+		writeJSON(w, http.StatusBadRequest, errs)
+		return
+	}
+	for k, v := range params {
+		address[k] = v
+	}
+	writeJSON(w, http.StatusOK, billingResponse(req, address))
+}
+
+func (api *billingAPI) handleGetProfile(w http.ResponseWriter, req *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if _, profile := api.billingFromRequest(w, req); profile != nil {
+		writeJSON(w, http.StatusOK, billingResponse(req, profile))
+	}
+}
+
+// handleUpdateProfile validates the billing contact as an email
+// address. Validation errors are keyed by property in the response's "detail".
+func (api *billingAPI) handleUpdateProfile(w http.ResponseWriter, req *http.Request) {
+	var params map[string]string
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"detail": "Invalid profile parameters"})
+		return
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	_, profile := api.billingFromRequest(w, req)
+	if profile == nil {
+		return
+	}
+	errs := map[string]string{}
+	for k, v := range params {
+		if !slices.Contains(profileProperties, k) {
+			errs[k] = "This field is not writable."
+		} else if k == "billing_contact" && v != "" && !strings.Contains(v, "@") {
+			errs[k] = "This value is not a valid email address."
+		}
+	}
+	if len(errs) > 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"status": http.StatusBadRequest, "detail": errs})
+		return
+	}
+	for k, v := range params {
+		profile[k] = v
+	}
+	writeJSON(w, http.StatusOK, billingResponse(req, profile))
+}
+
+// billingResponse returns the API representation of a billing address or
+// profile, which can be edited at its own URL.
+func billingResponse(req *http.Request, data map[string]any) map[string]any {
+	resp := make(map[string]any, len(data)+1)
+	for k, v := range data {
+		resp[k] = v
+	}
+	self := (&url.URL{Path: req.URL.Path}).String()
+	resp["_links"] = map[string]any{
+		"self":  map[string]any{"href": self},
+		"#edit": map[string]any{"href": self},
+	}
+	return resp
+}
@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertificateList(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t)
+	ca := s.TrustedCA()
+	intermediate := ca.Intermediate("Mock Intermediate CA")
+	autoIntermediate := ca.Intermediate("Mock Auto CA")
+
+	expires1, _ := time.Parse(time.RFC3339, "2099-01-01T00:00:00Z")
+	expires2, _ := time.Parse(time.RFC3339, "2098-01-01T00:00:00Z")
+	expired, _ := time.Parse(time.RFC3339, "2020-01-01T00:00:00Z")
+	created2, _ := time.Parse(time.RFC3339, "2014-04-02T10:00:00Z")
+	s.WithCertificates(
+		&certificateFixture{ID: "cert1", Cert: intermediate.LeafValidUntil(expires1, "example.com", "www.example.com")},
+		&certificateFixture{ID: "cert2", Cert: autoIntermediate.LeafValidUntil(expires2, "api.example.com"), Provisioned: true, CreatedAt: created2},
+		&certificateFixture{ID: "cert3", Cert: intermediate.LeafValidUntil(expired, "old.example.com")},
+	)
+	f, p := s.Factory(), s.ProjectID
+
+	stdOut, stdErr, err := f.RunCombinedOutput("certs", "-p", p)
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "Filters in use: --no-expired")
+	assertTrimmed(t, `
++-------+-----------------+---------------------------+---------------------------+----------------------+
+| ID    | Domain(s)       | Created                   | Expires                   | Issuer               |
++-------+-----------------+---------------------------+---------------------------+----------------------+
+| cert1 | example.com     | 2014-04-01T10:00:00+00:00 | 2099-01-01T00:00:00+00:00 | Mock Intermediate CA |
+|       | www.example.com |                           |                           |                      |
+| cert2 | api.example.com | 2014-04-02T10:00:00+00:00 | 2098-01-01T00:00:00+00:00 | Mock Auto CA         |
++-------+-----------------+---------------------------+---------------------------+----------------------+
+`, stdOut)
+
+	assertTrimmed(t, "cert3,old.example.com,2020-01-01T00:00:00+00:00",
+		f.Run("certs", "-p", p, "--only-expired", "--format", "csv", "--no-header", "--columns", "id,domains,expires"))
+
+	assertTrimmed(t, "example.com\nwww.example.com\napi.example.com", f.Run("certs", "-p", p, "--pipe-domains"))
+	assertTrimmed(t, "example.com\nwww.example.com\napi.example.com\nold.example.com", f.Run("certs", "-p", p, "--pipe-domains", "--ignore-expiry"))
+	assertTrimmed(t, "example.com\nwww.example.com", f.Run("certs", "-p", p, "--pipe-domains", "--no-auto"))
+	assertTrimmed(t, "api.example.com", f.Run("certs", "-p", p, "--pipe-domains", "--only-auto"))
+	assertTrimmed(t, "api.example.com", f.Run("certs", "-p", p, "--pipe-domains", "--issuer", "Mock Auto CA"))
+	assertTrimmed(t, "api.example.com", f.Run("certs", "-p", p, "--pipe-domains", "--domain", "API"))
+	assertTrimmed(t, "api.example.com", f.Run("certs", "-p", p, "--pipe-domains", "--exclude-domain", "www"))
+
+	_, stdErr, err = f.RunCombinedOutput("certs", "-p", p, "--domain", "missing.example.com")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "No certificates found")
+
+	assertTrimmed(t, "2099-01-01T00:00:00+00:00", f.Run("cert:get", "-p", p, "cert1", "-P", "expires_at"))
+	assertTrimmed(t, "- example.com\n- www.example.com", f.Run("cert:get", "-p", p, "cert1", "-P", "domains"))
+	assertTrimmed(t, "true", f.Run("cert:get", "-p", p, "cert2", "-P", "is_provisioned"))
+}
+
+func TestCertificateAdd(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t).WithCertificates()
+	f, p := s.Factory(), s.ProjectID
+	dir := t.TempDir()
+
+	intermediate := s.TrustedCA().Intermediate("Mock Intermediate CA")
+	leaf := intermediate.Leaf("example.com", "www.example.com")
+	certPath, keyPath, chainPath := leaf.WriteFiles(dir)
+
+	_, _, err := f.RunCombinedOutput("cert:add", "-p", p, "--cert", certPath, "--key", keyPath, "--chain", chainPath)
+	assert.NoError(t, err)
+	body := s.Recorder.RequireOne(t, "POST", "/projects/"+p+"/certificates").JSON(t)
+	assert.Equal(t, map[string]any{
+		"certificate": strings.TrimSpace(leaf.CertPEM()),
+		"key":         strings.TrimSpace(leaf.KeyPEM()),
+		"chain":       []any{strings.TrimSpace(intermediate.CertPEM())},
+	}, body)
+	assertTrimmed(t, "example.com\nwww.example.com", f.Run("certs", "-p", p, "--pipe-domains"))
+	id := certFingerprint(leaf.Cert)
+	assertTrimmed(t, "false", f.Run("cert:get", "-p", p, id[:8], "-P", "is_provisioned"))
+
+	_, stdErr, err := f.RunCombinedOutput("cert:add", "-p", p, "--cert", certPath)
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The --cert and --key options are required")
+
+	// The CLI rejects a mismatched key before sending it.
+	s.Recorder.Reset()
+	otherCert, otherKey, otherChain := intermediate.Leaf("other.example.com").WriteFiles(dir)
+	_, stdErr, err = f.RunCombinedOutput("cert:add", "-p", p, "--cert", certPath, "--key", otherKey, "--chain", chainPath)
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The provided certificate does not match the provided private key.")
+	s.Recorder.AssertNone(t, "POST", "/certificates")
+
+	// The API rejects expired and untrusted certificates.
+	expiredCert, expiredKey, expiredChain := intermediate.LeafValidUntil(time.Now().AddDate(0, 0, -1), "expired.example.com").WriteFiles(dir)
+	_, stdErr, err = f.RunCombinedOutput("cert:add", "-p", p, "--cert", expiredCert, "--key", expiredKey, "--chain", expiredChain)
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "certificate has expired or is not yet valid")
+	_, stdErr, err = f.RunCombinedOutput("cert:add", "-p", p, "--cert", otherCert, "--key", otherKey)
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "certificate signed by unknown authority")
+
+	assertTrimmed(t, "example.com\nwww.example.com", f.Run("certs", "-p", p, "--pipe-domains", "--ignore-expiry"))
+	_, _, err = f.RunCombinedOutput("cert:add", "-p", p, "--cert", otherCert, "--key", otherKey, "--chain", otherChain)
+	assert.NoError(t, err)
+	assertTrimmed(t, "example.com\nwww.example.com\nother.example.com", f.Run("certs", "-p", p, "--pipe-domains"))
+}
+
+func TestCertificateDelete(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t)
+	intermediate := s.TrustedCA().Intermediate("Mock Intermediate CA")
+	s.WithCertificates(
+		&certificateFixture{ID: "abc123", Cert: intermediate.Leaf("example.com")},
+		&certificateFixture{ID: "abd456", Cert: intermediate.Leaf("www.example.com")},
+		&certificateFixture{ID: "auto789", Cert: intermediate.Leaf("api.example.com"), Provisioned: true},
+	)
+	f, p := s.Factory(), s.ProjectID
+
+	session := f.RunInteractive("cert:delete", "-p", p, "abc123")
+	session.Expect("Are you sure you want to delete the certificate abc123? [Y/n]")
+	session.SendLine("n")
+	assert.Error(t, session.Wait())
+	s.Recorder.AssertNone(t, "DELETE", "/certificates/abc123")
+
+	_, stdErr, err := f.RunCombinedOutput("cert:delete", "-p", p, "ab")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, `The partial ID "ab" is ambiguous`)
+
+	_, stdErr, err = f.RunCombinedOutput("cert:delete", "-p", p, "abc")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "The certificate abc123 has been deleted.")
+	s.Recorder.AssertCount(t, 1, "DELETE", "/projects/"+p+"/certificates/abc123")
+
+	_, stdErr, err = f.RunCombinedOutput("cert:delete", "-p", p, "auto789")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The certificate auto789 is automatically provisioned; it cannot be deleted.")
+
+	_, stdErr, err = f.RunCombinedOutput("cert:delete", "-p", p, "missing")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, `Certificate not found: "missing"`)
+
+	assertTrimmed(t, "www.example.com\napi.example.com", f.Run("certs", "-p", p, "--pipe-domains"))
+}
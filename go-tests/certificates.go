@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/platformsh/cli/pkg/mockapi"
+)
+
+// certificateFixture is a project TLS certificate, served by the
+// certificates stand-in.
+type certificateFixture struct {
+	// ID defaults to the certificate's SHA-256 fingerprint.
+	ID   string
+	Cert *testCert
+	// Provisioned marks a certificate as automatically provisioned, so that
+	// it cannot be deleted.
+	Provisioned bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+
+	parsed      *x509.Certificate
+	certificate string
+	chain       []string
+}
+
+// certificatesAPI stands in for the project's certificates API.
+type certificatesAPI struct {
+	s     *scenario
+	roots *x509.CertPool
+
+	mu           sync.Mutex
+	certificates []*certificateFixture
+}
+
+// WithCertificates serves the project's certificates from a certificatesAPI,
+// and adds certificates. New certificates are checked against the scenario's
+// CA (see TrustedCA).
+func (s *scenario) WithCertificates(certs ...*certificateFixture) *scenario {
+	if s.certificates == nil {
+		s.certificates = &certificatesAPI{s: s, roots: s.trustedRoots()}
+		s.Project.Links["certificates"] = mockapi.HALLink{HREF: "/projects/" + url.PathEscape(s.ProjectID) + "/certificates"}
+		s.Use(standIn(s.certificates.routes))
+	}
+	s.certificates.add(certs...)
+	return s
+}
+
+func (api *certificatesAPI) routes(r chi.Router) {
+	r.Get("/projects/{project}/certificates", api.handleList)
+	r.Post("/projects/{project}/certificates", api.handleAdd)
+	r.Get("/projects/{project}/certificates/{id}", api.handleGet)
+	r.Delete("/projects/{project}/certificates/{id}", api.handleDelete)
+}
+
+func (api *certificatesAPI) add(certs ...*certificateFixture) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	for _, c := range certs {
+		if c.CreatedAt.IsZero() {
+			c.CreatedAt, _ = time.Parse(time.RFC3339, "2014-04-01T10:00:00Z")
+		}
+		if c.UpdatedAt.IsZero() {
+			c.UpdatedAt = c.CreatedAt
+		}
+		c.parsed = c.Cert.Cert
+		c.certificate = strings.TrimSpace(c.Cert.CertPEM())
+		c.chain = splitPEM(c.Cert.ChainPEM())
+		if c.ID == "" {
+			c.ID = certFingerprint(c.parsed)
+		}
+		api.certificates = append(api.certificates, c)
+	}
+}
+
+func certFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func (api *certificatesAPI) find(id string) *certificateFixture {
+	for _, c := range api.certificates {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+func (api *certificatesAPI) handleList(w http.ResponseWriter, _ *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	list := []any{}
+	for _, c := range api.certificates {
+		list = append(list, api.certificateData(c))
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (api *certificatesAPI) handleGet(w http.ResponseWriter, req *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	c := api.find(chi.URLParam(req, "id"))
+	if c == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Certificate not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, api.certificateData(c))
+}
+
+func (api *certificatesAPI) handleAdd(w http.ResponseWriter, req *http.Request) {
+	var params certParams
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": "Invalid certificate parameters"})
+		return
+	}
+	if err := verifyCertChain("", params.Certificate, params.Key, params.Chain, api.roots); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return
+	}
+	parsed := parsePEMCerts(params.Certificate)[0]
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if api.find(certFingerprint(parsed)) != nil {
+		writeJSON(w, http.StatusConflict, map[string]any{"message": "The certificate already exists"})
+		return
+	}
+	c := &certificateFixture{
+		ID:          certFingerprint(parsed),
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+		UpdatedAt:   time.Now().UTC().Truncate(time.Second),
+		parsed:      parsed,
+		certificate: params.Certificate,
+		chain:       params.Chain,
+	}
+	api.certificates = append(api.certificates, c)
+	writeJSON(w, http.StatusCreated, map[string]any{"_embedded": map[string]any{"entity": api.certificateData(c)}})
+}
+
+func (api *certificatesAPI) handleDelete(w http.ResponseWriter, req *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	c := api.find(chi.URLParam(req, "id"))
+	if c == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Certificate not found"})
+		return
+	}
+	if c.Provisioned {
+		writeJSON(w, http.StatusForbidden, map[string]any{"message": "Automatically provisioned certificates cannot be deleted"})
+		return
+	}
+	var remaining []*certificateFixture
+	for _, other := range api.certificates {
+		if other != c {
+			remaining = append(remaining, other)
+		}
+	}
+	api.certificates = remaining
+	writeJSON(w, http.StatusOK, map[string]any{"_embedded": map[string]any{"activities": []any{}}})
+}
+
+// certificateData returns the API representation of a certificate.
+func (api *certificatesAPI) certificateData(c *certificateFixture) map[string]any {
+	self := "/projects/" + url.PathEscape(api.s.ProjectID) + "/certificates/" + url.PathEscape(c.ID)
+	issuer := []map[string]any{{"oid": "2.5.4.3", "alias": "commonName", "value": c.parsed.Issuer.CommonName}}
+	for _, o := range c.parsed.Issuer.Organization {
+		issuer = append(issuer, map[string]any{"oid": "2.5.4.10", "alias": "organizationName", "value": o})
+	}
+	return map[string]any{
+		"id":             c.ID,
+		"certificate":    c.certificate,
+		"chain":          c.chain,
+		"domains":        c.parsed.DNSNames,
+		"issuer":         issuer,
+		"is_provisioned": c.Provisioned,
+		"is_invalid":     false,
+		"created_at":     c.CreatedAt.Format(time.RFC3339),
+		"updated_at":     c.UpdatedAt.Format(time.RFC3339),
+		"expires_at":     c.parsed.NotAfter.UTC().Format(time.RFC3339),
+		"_links": map[string]any{
+			"self":    map[string]any{"href": self},
+			"#delete": map[string]any{"href": self},
+		},
+	}
+}
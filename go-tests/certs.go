@@ -0,0 +1,179 @@
+package tests
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testCert is a certificate and its private key, generated in-process. A
+// certificate authority (newTestCA) issues intermediates and leaf certificates.
+type testCert struct {
+	t      *testing.T
+	Cert   *x509.Certificate
+	Key    *ecdsa.PrivateKey
+	Issuer *testCert
+}
+
+// newTestCA creates a self-signed root certificate authority.
+func newTestCA(t *testing.T, name string) *testCert {
+	return issueCert(t, nil, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name, Organization: []string{"Mock CA"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	})
+}
+
+// Intermediate issues an intermediate certificate authority.
+func (c *testCert) Intermediate(name string) *testCert {
+	return issueCert(c.t, c, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name, Organization: []string{"Mock CA"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(5, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	})
+}
+
+// Leaf issues a server certificate for the given domains, valid for 90 days.
+func (c *testCert) Leaf(domains ...string) *testCert {
+	return c.LeafValidUntil(time.Now().AddDate(0, 0, 90), domains...)
+}
+
+// LeafValidUntil issues a server certificate for the given domains, which
+// expires at the given time (possibly in the past), after 90 days of validity.
+func (c *testCert) LeafValidUntil(notAfter time.Time, domains ...string) *testCert {
+	return issueCert(c.t, c, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: domains[0]},
+		DNSNames:    domains,
+		NotBefore:   notAfter.AddDate(0, 0, -90),
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+}
+
+func issueCert(t *testing.T, issuer *testCert, template *x509.Certificate) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template.SerialNumber, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	require.NoError(t, err)
+
+	parent, signer := template, key
+	if issuer != nil {
+		parent, signer = issuer.Cert, issuer.Key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCert{t: t, Cert: cert, Key: key, Issuer: issuer}
+}
+
+// CertPEM returns the PEM-encoded certificate.
+func (c *testCert) CertPEM() string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Cert.Raw}))
+}
+
+// KeyPEM returns the PEM-encoded private key, in PKCS #8 form.
+func (c *testCert) KeyPEM() string {
+	der, err := x509.MarshalPKCS8PrivateKey(c.Key)
+	require.NoError(c.t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+// ChainPEM returns the PEM-encoded intermediate certificates which issued
+// this one, nearest first. The root is not included, as in a chain file.
+func (c *testCert) ChainPEM() string {
+	var b strings.Builder
+	for i := c.Issuer; i != nil && i.Issuer != nil; i = i.Issuer {
+		b.WriteString(i.CertPEM())
+	}
+	return b.String()
+}
+
+// WriteFiles writes the certificate, key and chain as files in a directory,
+// and returns their paths.
+func (c *testCert) WriteFiles(dir string) (certPath, keyPath, chainPath string) {
+	name := strings.ReplaceAll(c.Cert.Subject.CommonName, " ", "-")
+	certPath = filepath.Join(dir, name+".crt")
+	keyPath = filepath.Join(dir, name+".key")
+	chainPath = filepath.Join(dir, name+".chain.crt")
+	require.NoError(c.t, os.WriteFile(certPath, []byte(c.CertPEM()), 0o600))
+	require.NoError(c.t, os.WriteFile(keyPath, []byte(c.KeyPEM()), 0o600))
+	require.NoError(c.t, os.WriteFile(chainPath, []byte(c.ChainPEM()), 0o600))
+	return certPath, keyPath, chainPath
+}
+
+// verifyCertChain checks PEM-encoded certificate data as the API would: the
+// key must match the certificate, and the certificate must be current, valid
+// for the domain name (unless it is empty), and issued (through the chain) by
+// one of the roots.
+func verifyCertChain(domain, certPEM, keyPEM string, chainPEM []string, roots *x509.CertPool) error {
+	pair, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return fmt.Errorf("invalid certificate or private key: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("invalid certificate: %w", err)
+	}
+	intermediates := x509.NewCertPool()
+	for _, p := range chainPEM {
+		if !intermediates.AppendCertsFromPEM([]byte(p)) {
+			return errors.New("invalid X509 certificate in the chain")
+		}
+	}
+	_, err = leaf.Verify(x509.VerifyOptions{DNSName: domain, Roots: roots, Intermediates: intermediates})
+	if err != nil {
+		return fmt.Errorf("invalid certificate chain: %w", err)
+	}
+	return nil
+}
+
+// splitPEM splits PEM data into its blocks, each trimmed of surrounding
+// whitespace, as the CLI reads a chain file.
+func splitPEM(data string) []string {
+	blocks := []string{}
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return blocks
+		}
+		blocks = append(blocks, strings.TrimSpace(string(pem.EncodeToMemory(block))))
+	}
+}
+
+// parsePEMCerts parses the certificates in PEM data, ignoring invalid blocks.
+func parsePEMCerts(data string) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for _, p := range splitPEM(data) {
+		block, _ := pem.Decode([]byte(p))
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+	return certs
+}
@@ -0,0 +1,243 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupDomainTest creates a scenario with production and development
+// environments which support domains.
+func setupDomainTest(t *testing.T) *scenario {
+	return newScenario(t).
+		WithEnv("main", "production", "active", nil, envDomains).
+		WithEnv("dev", "development", "active", "main", envDomains).
+		WithEnv("staging", "staging", "active", "main", envDomains).
+		WithNonProductionDomains()
+}
+
+func TestDomainList(t *testing.T) {
+	t.Parallel()
+	s := setupDomainTest(t)
+	created1, _ := time.Parse(time.RFC3339, "2014-04-01T10:00:00Z")
+	created2, _ := time.Parse(time.RFC3339, "2014-04-02T10:00:00Z")
+	created3, _ := time.Parse(time.RFC3339, "2014-04-03T10:00:00Z")
+	s.WithDomains(
+		&domainFixture{
+			Name:      "example.com",
+			Cert:      s.TrustedCA().Intermediate("Mock Intermediate CA").Leaf("example.com"),
+			CreatedAt: created1,
+			UpdatedAt: created1,
+		},
+		&domainFixture{Name: "www.example.com", CreatedAt: created2, UpdatedAt: created2},
+		&domainFixture{Name: "dev.example.com", Environment: "dev", ReplacementFor: "example.com", CreatedAt: created3, UpdatedAt: created3},
+	)
+	f, p := s.Factory(), s.ProjectID
+
+	assertTrimmed(t, `
++-----------------+-------------+---------------------------+
+| Name            | SSL enabled | Creation date             |
++-----------------+-------------+---------------------------+
+| example.com     | true        | 2014-04-01T10:00:00+00:00 |
+| www.example.com | false       | 2014-04-02T10:00:00+00:00 |
++-----------------+-------------+---------------------------+
+`, f.Run("domains", "-p", p))
+
+	assertTrimmed(t, `
++-----------------+-------------+---------------------------+-----------------+
+| Name            | SSL enabled | Creation date             | Attached domain |
++-----------------+-------------+---------------------------+-----------------+
+| dev.example.com | false       | 2014-04-03T10:00:00+00:00 | example.com     |
++-----------------+-------------+---------------------------+-----------------+
+`, f.Run("domains", "-p", p, "-e", "dev"))
+
+	assertTrimmed(t, "environment", f.Run("domain:get", "-p", p, "-e", "dev", "dev.example.com", "-P", "type"))
+	assertTrimmed(t, "true", f.Run("domain:get", "-p", p, "example.com", "-P", "ssl.has_certificate"))
+
+	_, stdErr, err := f.RunCombinedOutput("domains", "-p", p, "-e", "staging")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "No domains found for the environment")
+
+	_, stdErr, err = f.RunCombinedOutput("domain:get", "-p", p, "missing.example.com")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Domain not found: missing.example.com")
+}
+
+func TestDomainAdd(t *testing.T) {
+	t.Parallel()
+	s := setupDomainTest(t).WithDomains()
+	f, p := s.Factory(), s.ProjectID
+
+	intermediate := s.TrustedCA().Intermediate("Mock Intermediate CA")
+	leaf := intermediate.Leaf("example.com")
+	certPath, keyPath, chainPath := leaf.WriteFiles(t.TempDir())
+
+	_, stdErr, err := f.RunCombinedOutput("domain:add", "-p", p, "example.com", "--cert", certPath, "--key", keyPath, "--chain", chainPath)
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "Adding the domain: example.com")
+	body := s.Recorder.RequireOne(t, "POST", "/environments/main/domains").JSON(t)
+	assert.Equal(t, "example.com", body["name"])
+	assert.Equal(t, map[string]any{
+		"certificate": strings.TrimSpace(leaf.CertPEM()),
+		"key":         strings.TrimSpace(leaf.KeyPEM()),
+		"chain":       []any{strings.TrimSpace(intermediate.CertPEM())},
+	}, body["ssl"])
+	assertTrimmed(t, "true", f.Run("domain:get", "-p", p, "example.com", "-P", "ssl.has_certificate"))
+
+	// A non-production domain is attached to the only production domain.
+	s.Recorder.Reset()
+	_, stdErr, err = f.RunCombinedOutput("domain:add", "-p", p, "-e", "dev", "dev.example.com")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "It will be attached to the production domain: example.com")
+	s.Recorder.AssertOneJSON(t, "POST", "/environments/dev/domains", `{"name": "dev.example.com", "replacement_for": "example.com"}`)
+
+	_, stdErr, err = f.RunCombinedOutput("domain:add", "-p", p, "-e", "dev", "dev2.example.com", "--attach", "example.com")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "already has a domain with the same --attach value: dev.example.com")
+
+	_, stdErr, err = f.RunCombinedOutput("domain:add", "-p", p, "-e", "staging", "staging.example.com", "--attach", "missing.example.com")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The production domain (--attach) was not found: missing.example.com")
+
+	_, stdErr, err = f.RunCombinedOutput("domain:add", "-p", p, "-e", "main", "www.example.com", "--attach", "example.com")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The --attach option is only valid for non-production environment domains.")
+
+	_, stdErr, err = f.RunCombinedOutput("domain:add", "-p", p, "not-a-domain")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "You must specify a valid domain name")
+}
+
+func TestDomainAddInvalidCertificate(t *testing.T) {
+	t.Parallel()
+	s := setupDomainTest(t).WithDomains()
+	f, p := s.Factory(), s.ProjectID
+	dir := t.TempDir()
+
+	intermediate := s.TrustedCA().Intermediate("Mock Intermediate CA")
+	leaf := intermediate.Leaf("example.com")
+	certPath, keyPath, chainPath := leaf.WriteFiles(dir)
+
+	otherIntermediate := s.TrustedCA().Intermediate("Other Intermediate CA")
+	_, _, otherChainPath := otherIntermediate.Leaf("example.com").WriteFiles(t.TempDir())
+	otherHostCert, otherHostKey, _ := intermediate.Leaf("other.example.com").WriteFiles(dir)
+	untrustedCert, untrustedKey, untrustedChain := newTestCA(t, "Untrusted CA").Intermediate("Untrusted Intermediate CA").Leaf("untrusted.example.com").WriteFiles(dir)
+
+	invalidChainPath := filepath.Join(dir, "invalid.chain.crt")
+	require.NoError(t, os.WriteFile(invalidChainPath, []byte("-----BEGIN CERTIFICATE-----\nbm90IGEgY2VydGlmaWNhdGU=\n-----END CERTIFICATE-----\n"), 0o600))
+
+	// The CLI checks the key and the chain file itself.
+	cliCases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"missing key", []string{"--cert", certPath}, "Both the --cert and the --key are required for SSL certificates"},
+		{"mismatched key", []string{"--cert", certPath, "--key", otherHostKey, "--chain", chainPath}, "The provided certificate does not match the provided private key."},
+		{"invalid chain file", []string{"--cert", certPath, "--key", keyPath, "--chain", invalidChainPath}, "The chain file contains an invalid X509 certificate: " + invalidChainPath},
+	}
+	for _, c := range cliCases {
+		_, stdErr, err := f.RunCombinedOutput(append([]string{"domain:add", "-p", p, "example.com"}, c.args...)...)
+		assertExitCode(t, 1, err)
+		assert.Contains(t, stdErr, c.expected, c.name)
+	}
+	s.Recorder.AssertNone(t, "POST", "/domains")
+
+	// The API verifies the chain and the domain, and the CLI prints its error.
+	untrusted := "The SSL certificate chain is incomplete, or is not issued by a trusted certificate authority."
+	apiCases := []struct {
+		name     string
+		domain   string
+		args     []string
+		expected string
+	}{
+		{"incomplete chain", "example.com", []string{"--cert", certPath, "--key", keyPath}, untrusted},
+		{"wrong chain", "example.com", []string{"--cert", certPath, "--key", keyPath, "--chain", otherChainPath}, untrusted},
+		{"untrusted root", "untrusted.example.com", []string{"--cert", untrustedCert, "--key", untrustedKey, "--chain", untrustedChain}, untrusted},
+		{"wrong domain", "example.com", []string{"--cert", otherHostCert, "--key", otherHostKey, "--chain", chainPath}, "The SSL certificate is not valid for the domain example.com."},
+	}
+	for _, c := range apiCases {
+		s.Recorder.Reset()
+		_, stdErr, err := f.RunCombinedOutput(append([]string{"domain:add", "-p", p, c.domain}, c.args...)...)
+		assertExitCode(t, 1, err)
+		s.Recorder.AssertCount(t, 1, "POST", "/domains")
+		assert.Contains(t, stdErr, "\n"+c.expected+"\n", c.name)
+	}
+
+	_, stdErr, err := f.RunCombinedOutput("domains", "-p", p)
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "No domains found")
+}
+
+func TestDomainUpdate(t *testing.T) {
+	t.Parallel()
+	s := setupDomainTest(t).WithDomains(&domainFixture{Name: "example.com"})
+	f, p := s.Factory(), s.ProjectID
+	dir := t.TempDir()
+
+	intermediate := s.TrustedCA().Intermediate("Mock Intermediate CA")
+	_, stdErr, err := f.RunCombinedOutput("domain:update", "-p", p, "example.com")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "There is nothing to update.")
+	s.Recorder.AssertNone(t, "PATCH", "/domains/example.com")
+
+	leaf := intermediate.Leaf("example.com")
+	certPath, keyPath, chainPath := leaf.WriteFiles(dir)
+	_, stdErr, err = f.RunCombinedOutput("domain:update", "-p", p, "example.com", "--cert", certPath, "--key", keyPath, "--chain", chainPath)
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "Updating the domain example.com")
+	body := s.Recorder.RequireOne(t, "PATCH", "/projects/"+p+"/domains/example.com").JSON(t)
+	assert.Equal(t, map[string]any{"ssl": map[string]any{
+		"certificate": strings.TrimSpace(leaf.CertPEM()),
+		"key":         strings.TrimSpace(leaf.KeyPEM()),
+		"chain":       []any{strings.TrimSpace(intermediate.CertPEM())},
+	}}, body)
+	assertTrimmed(t, "true", f.Run("domain:get", "-p", p, "example.com", "-P", "ssl.has_certificate"))
+
+	// The API rejects a certificate for another domain.
+	otherCert, otherKey, otherChain := intermediate.Leaf("www.example.com").WriteFiles(dir)
+	_, _, err = f.RunCombinedOutput("domain:update", "-p", p, "example.com", "--cert", otherCert, "--key", otherKey, "--chain", otherChain)
+	assertExitCode(t, 1, err)
+	assertTrimmed(t, leaf.CertPEM(), f.Run("domain:get", "-p", p, "example.com", "-P", "ssl.certificate"))
+
+	_, stdErr, err = f.RunCombinedOutput("domain:update", "-p", p, "missing.example.com", "--cert", certPath, "--key", keyPath)
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Domain not found: missing.example.com")
+}
+
+func TestDomainDelete(t *testing.T) {
+	t.Parallel()
+	s := setupDomainTest(t).WithDomains(
+		&domainFixture{Name: "example.com"},
+		&domainFixture{Name: "www.example.com"},
+		&domainFixture{Name: "dev.example.com", Environment: "dev", ReplacementFor: "example.com"},
+	)
+	f, p := s.Factory(), s.ProjectID
+
+	session := f.RunInteractive("domain:delete", "-p", p, "example.com")
+	session.Expect("If this domain has non-production domains attached to it, they will also be deleted.")
+	session.Expect("Are you sure you want to delete the domain example.com? [Y/n]")
+	session.SendLine("n")
+	assert.Error(t, session.Wait())
+	s.Recorder.AssertNone(t, "DELETE", "/domains/example.com")
+
+	_, stdErr, err := f.RunCombinedOutput("domain:delete", "-p", p, "example.com")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "The domain example.com has been deleted.")
+	s.Recorder.AssertCount(t, 1, "DELETE", "/projects/"+p+"/domains/example.com")
+
+	// The attached non-production domain was deleted too.
+	assertTrimmed(t, "www.example.com", f.Run("domains", "-p", p, "--format", "plain", "--no-header", "--columns", "name"))
+	_, stdErr, err = f.RunCombinedOutput("domains", "-p", p, "-e", "dev")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "No domains found")
+
+	_, stdErr, err = f.RunCombinedOutput("domain:delete", "-p", p, "missing.example.com")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Domain not found: missing.example.com")
+}
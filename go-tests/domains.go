@@ -0,0 +1,359 @@
+package tests
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/platformsh/cli/pkg/mockapi"
+)
+
+// domainFixture is a domain served by the domains stand-in.
+type domainFixture struct {
+	Name string
+	// Environment is empty for a production domain, which is listed on the
+	// project (and on its default environment).
+	Environment string
+	// ReplacementFor is the production domain which a non-production domain
+	// is attached to.
+	ReplacementFor string
+	// Cert is a custom certificate, if any.
+	Cert      *testCert
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	certificate string
+	chain       []string
+}
+
+// domainsAPI stands in for the project's domains API.
+type domainsAPI struct {
+	s     *scenario
+	roots *x509.CertPool
+
+	mu      sync.Mutex
+	domains []*domainFixture
+}
+
+// WithDomains serves the project's domains from a domainsAPI, and adds
+// domains. Environments need the envDomains capability for their own domains.
+//
+// Custom certificates are checked against the scenario's CA (see TrustedCA),
+// as the API checks them against public certificate authorities.
+func (s *scenario) WithDomains(domains ...*domainFixture) *scenario {
+	if s.domains == nil {
+		s.domains = &domainsAPI{s: s, roots: s.trustedRoots()}
+		projectPath := "/projects/" + url.PathEscape(s.ProjectID)
+		s.Project.Links["domains"] = mockapi.HALLink{HREF: projectPath + "/domains"}
+		s.Project.Links["#manage-domains"] = mockapi.HALLink{HREF: projectPath + "/domains"}
+		s.serveCapabilities()
+		s.Use(standIn(s.domains.routes))
+	}
+	s.domains.add(domains...)
+	return s
+}
+
+// WithNonProductionDomains enables the project capability for domains on
+// non-production environments.
+func (s *scenario) WithNonProductionDomains() *scenario {
+	s.serveCapabilities().enableNonProductionDomains()
+	return s
+}
+
+func (api *domainsAPI) routes(r chi.Router) {
+	for _, base := range []string{
+		"/projects/{project}/domains",
+		"/projects/{project}/environments/{environment}/domains",
+	} {
+		r.Get(base, api.handleList)
+		r.Post(base, api.handleCreate)
+		r.Get(base+"/{name}", api.handleGet)
+		r.Patch(base+"/{name}", api.handleUpdate)
+		r.Delete(base+"/{name}", api.handleDelete)
+	}
+}
+
+func (api *domainsAPI) add(domains ...*domainFixture) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	for _, d := range domains {
+		if d.CreatedAt.IsZero() {
+			d.CreatedAt, _ = time.Parse(time.RFC3339, "2014-04-01T10:00:00Z")
+		}
+		if d.UpdatedAt.IsZero() {
+			d.UpdatedAt = d.CreatedAt
+		}
+		if d.Cert != nil {
+			d.certificate = strings.TrimSpace(d.Cert.CertPEM())
+			d.chain = splitPEM(d.Cert.ChainPEM())
+		}
+		api.domains = append(api.domains, d)
+	}
+}
+
+// TrustedCA returns a root certificate authority, which stand-in APIs
+// trust when validating certificates.
+func (s *scenario) TrustedCA() *testCert {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.trustedCA()
+}
+
+func (s *scenario) trustedCA() *testCert {
+	if s.ca == nil {
+		s.ca = newTestCA(s.t, "Mock Root CA")
+	}
+	return s.ca
+}
+
+// trustedRoots returns a pool containing the scenario's CA.
+func (s *scenario) trustedRoots() *x509.CertPool {
+	roots := x509.NewCertPool()
+	roots.AddCert(s.TrustedCA().Cert)
+	return roots
+}
+
+// domainEnv returns the environment whose domains are requested, or an empty
+// string for production domains.
+func (api *domainsAPI) domainEnv(req *http.Request) string {
+	env := chi.URLParam(req, "environment")
+	if env == api.s.Project.DefaultBranch {
+		return ""
+	}
+	return env
+}
+
+func (api *domainsAPI) find(env, name string) *domainFixture {
+	for _, d := range api.domains {
+		if d.Environment == env && d.Name == name {
+			return d
+		}
+	}
+	return nil
+}
+
+func (api *domainsAPI) handleList(w http.ResponseWriter, req *http.Request) {
+	env := api.domainEnv(req)
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	list := []any{}
+	for _, d := range api.domains {
+		if d.Environment == env {
+			list = append(list, api.domainData(d))
+		}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (api *domainsAPI) handleGet(w http.ResponseWriter, req *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	d := api.find(api.domainEnv(req), chi.URLParam(req, "name"))
+	if d == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Domain not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, api.domainData(d))
+}
+
+// certParams is the custom certificate of a domain, or a project certificate,
+// as sent by the CLI.
+type certParams struct {
+	Certificate string   `json:"certificate"`
+	Key         string   `json:"key"`
+	Chain       []string `json:"chain"`
+}
+
+func (api *domainsAPI) handleCreate(w http.ResponseWriter, req *http.Request) {
+	var params struct {
+		Name           string      `json:"name"`
+		ReplacementFor string      `json:"replacement_for"`
+		SSL            *certParams `json:"ssl"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil || params.Name == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"detail": map[string]any{"error": "Invalid domain parameters"}})
+		return
+	}
+	env := api.domainEnv(req)
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if api.find(env, params.Name) != nil {
+		writeJSON(w, http.StatusConflict, map[string]any{"message": "The domain already exists: " + params.Name})
+		return
+	}
+	if env != "" {
+		if api.find("", params.ReplacementFor) == nil {
+			var prodDomains []string
+			for _, d := range api.domains {
+				if d.Environment == "" {
+					prodDomains = append(prodDomains, d.Name)
+				}
+			}
+			writeJSON(w, http.StatusConflict, map[string]any{
+				"message": "The replacement_for domain has no corresponding domain set on the production environment",
+				"detail":  map[string]any{"prod-domains": prodDomains},
+			})
+			return
+		}
+		for _, d := range api.domains {
+			if d.Environment == env && d.ReplacementFor == params.ReplacementFor {
+				writeJSON(w, http.StatusConflict, map[string]any{
+					"message": "The environment already has a domain with the same replacement_for",
+					"detail":  map[string]any{"conflicting_domain": d.Name},
+				})
+				return
+			}
+		}
+	}
+
+	d := &domainFixture{
+		Name:        params.Name,
+		Environment: env,
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+		UpdatedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+	if env != "" {
+		d.ReplacementFor = params.ReplacementFor
+	}
+	if params.SSL != nil && !api.applySSL(w, d, params.SSL) {
+		return
+	}
+	api.domains = append(api.domains, d)
+	writeJSON(w, http.StatusCreated, map[string]any{"_embedded": map[string]any{"entity": api.domainData(d)}})
+}
+
+func (api *domainsAPI) handleUpdate(w http.ResponseWriter, req *http.Request) {
+	var params struct {
+		SSL *certParams `json:"ssl"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"detail": map[string]any{"error": "Invalid domain parameters"}})
+		return
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	d := api.find(api.domainEnv(req), chi.URLParam(req, "name"))
+	if d == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Domain not found"})
+		return
+	}
+	if params.SSL != nil && !api.applySSL(w, d, params.SSL) {
+		return
+	}
+	d.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	writeJSON(w, http.StatusOK, map[string]any{"_embedded": map[string]any{"entity": api.domainData(d)}})
+}
+
+// applyDomainSSL validates and sets a domain's custom certificate, or writes
+// an error response.
+func (api *domainsAPI) applySSL(w http.ResponseWriter, d *domainFixture, ssl *certParams) bool {
+	if err := verifyCertChain(d.Name, ssl.Certificate, ssl.Key, ssl.Chain, api.roots); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"detail": map[string]any{"error": domainSSLError(d.Name, err)}})
+		return false
+	}
+	d.certificate, d.chain = ssl.Certificate, ssl.Chain
+	return true
+}
+
+// domainSSLError words a certificate verification error as the API does.
+func domainSSLError(domain string, err error) string {
+	var (
+		hostnameErr  x509.HostnameError
+		authorityErr x509.UnknownAuthorityError
+		invalidErr   x509.CertificateInvalidError
+	)
+	switch {
+	case errors.As(err, &hostnameErr):
+		return "The SSL certificate is not valid for the domain " + domain + "."
+	case errors.As(err, &authorityErr):
+		return "The SSL certificate chain is incomplete, or is not issued by a trusted certificate authority."
+	case errors.As(err, &invalidErr) && invalidErr.Reason == x509.Expired:
+		return "The SSL certificate has expired."
+	}
+	return "The SSL certificate or private key is not valid."
+}
+
+func (api *domainsAPI) handleDelete(w http.ResponseWriter, req *http.Request) {
+	env, name := api.domainEnv(req), chi.URLParam(req, "name")
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if api.find(env, name) == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Domain not found"})
+		return
+	}
+	// Deleting a production domain also deletes the domains attached to it.
+	var remaining []*domainFixture
+	for _, d := range api.domains {
+		deleted := d.Environment == env && d.Name == name
+		attached := env == "" && d.Environment != "" && d.ReplacementFor == name
+		if !deleted && !attached {
+			remaining = append(remaining, d)
+		}
+	}
+	api.domains = remaining
+	writeJSON(w, http.StatusOK, map[string]any{"_embedded": map[string]any{"activities": []any{}}})
+}
+
+// domainData returns the API representation of a domain.
+func (api *domainsAPI) domainData(d *domainFixture) map[string]any {
+	base := "/projects/" + url.PathEscape(api.s.ProjectID)
+	domainType := "production"
+	if d.Environment != "" {
+		base += "/environments/" + url.PathEscape(d.Environment)
+		domainType = "environment"
+	}
+	self := base + "/domains/" + url.PathEscape(d.Name)
+
+	ssl := map[string]any{
+		"has_certificate": d.certificate != "",
+		"certificate":     nil,
+		"key":             nil,
+		"chain":           []string{},
+		"expires_on":      nil,
+	}
+	if d.certificate != "" {
+		ssl["certificate"] = d.certificate
+		ssl["chain"] = d.chain
+		if certs := parsePEMCerts(d.certificate); len(certs) > 0 {
+			ssl["expires_on"] = certs[0].NotAfter.UTC().Format(time.RFC3339)
+		}
+	}
+	data := map[string]any{
+		"id":              d.Name,
+		"name":            d.Name,
+		"type":            domainType,
+		"project":         api.s.ProjectID,
+		"registered_name": registeredName(d.Name),
+		"ssl":             ssl,
+		"created_at":      d.CreatedAt.Format(time.RFC3339),
+		"updated_at":      d.UpdatedAt.Format(time.RFC3339),
+		"_links": map[string]any{
+			"self":    map[string]any{"href": self},
+			"#edit":   map[string]any{"href": self},
+			"#delete": map[string]any{"href": self},
+		},
+	}
+	if d.Environment != "" {
+		data["environment"] = d.Environment
+		data["replacement_for"] = d.ReplacementFor
+	}
+	return data
+}
+
+// registeredName returns the last two labels of a domain name, e.g.
+// "example.com" for "www.example.com".
+func registeredName(name string) string {
+	labels := strings.Split(name, ".")
+	if len(labels) <= 2 {
+		return name
+	}
+	return strings.Join(labels[len(labels)-2:], ".")
+}
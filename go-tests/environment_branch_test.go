@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvironmentBranch(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil, envBranch).
+		WithGitRepository()
+	rev := s.Git.Seed(s.ProjectID, "main", map[string]string{"README.md": "# Test project\n"})
+	f := s.Clone()
+	dir := f.WorkDir()
+
+	_, stdErr, err := f.RunCombinedOutput("environment:branch", "feature", "--title", "New feature")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Creating a new environment: New feature (feature)")
+	assert.Contains(t, stdErr, "Creating local branch feature")
+	assert.Contains(t, stdErr, "Setting the upstream for the local branch to: platform-test/feature")
+
+	branchRequest := s.Recorder.RequireOne(t, "POST", "/environments/main/branch").JSON(t)
+	assert.Equal(t, "feature", branchRequest["name"])
+	assert.Equal(t, "New feature", branchRequest["title"])
+	assert.Equal(t, true, branchRequest["clone_parent"])
+
+	assert.Equal(t, rev, s.Git.Branches(s.ProjectID)["feature"])
+	assert.Equal(t, "feature", runGit(t, dir, "branch", "--show-current"))
+	assert.Equal(t, "platform-test/feature", runGit(t, dir, "rev-parse", "--abbrev-ref", "feature@{upstream}"))
+
+	activities := s.Activities()
+	require.Len(t, activities, 1)
+	assert.Equal(t, "environment.branch", activities[0].Type)
+	assert.Equal(t, []string{"main", "feature"}, activities[0].Environments)
+
+	assertTrimmed(t, `
+ID	Title	Status
+main	Main	Active
+feature	New feature	Active
+`, f.Run("environment:list", "--format", "plain", "--columns", "id,title,status"))
+
+	// The new environment cannot be branched, as it was created without the capability.
+	_, stdErr, err = f.RunCombinedOutput("environment:branch", "feature2", "-e", "feature")
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "can't be branched")
+	s.Recorder.AssertNone(t, "POST", "/environments/feature/branch")
+}
@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvironmentCheckout(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil).
+		WithEnv("dev", "development", "active", "main").
+		WithGitRepository()
+	s.Git.Seed(s.ProjectID, "main", map[string]string{"README.md": "# Test project\n"})
+	devRev := s.Git.Seed(s.ProjectID, "dev", map[string]string{"dev.txt": "Development\n"})
+	f := s.Clone()
+	dir := f.WorkDir()
+
+	_, stdErr, err := f.RunCombinedOutput("environment:checkout", "dev")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Creating local branch dev based on upstream platform-test/dev")
+	assert.Equal(t, "dev", runGit(t, dir, "branch", "--show-current"))
+	assert.Equal(t, devRev, runGit(t, dir, "rev-parse", "HEAD"))
+	assert.Equal(t, "platform-test/dev", runGit(t, dir, "rev-parse", "--abbrev-ref", "dev@{upstream}"))
+
+	_, stdErr, err = f.RunCombinedOutput("environment:checkout", "main")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Checking out main")
+	assert.Equal(t, "main", runGit(t, dir, "branch", "--show-current"))
+
+	_, stdErr, err = f.RunCombinedOutput("environment:checkout", "missing")
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "Branch not found: missing")
+	assert.Empty(t, s.Git.Pushes())
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/platformsh/cli/pkg/mockapi"
+)
+
+func TestEnvironmentDeleteInteractive(t *testing.T) {
+	t.Parallel()
+	authServer := mockapi.NewAuthServer(t)
+	defer authServer.Close()
+
+	apiHandler := mockapi.NewHandler(t)
+	apiServer := httptest.NewServer(apiHandler)
+	defer apiServer.Close()
+
+	projectID := mockapi.ProjectID()
+	apiHandler.SetProjects([]*mockapi.Project{
+		{
+			ID: projectID,
+			Links: mockapi.MakeHALLinks(
+				"self=/projects/"+projectID,
+				"environments=/projects/"+projectID+"/environments",
+			),
+			DefaultBranch: "main",
+		},
+	})
+	apiHandler.SetEnvironments([]*mockapi.Environment{
+		makeEnv(projectID, "main", "production", "active", nil),
+		makeEnv(projectID, "dev", "development", "active", "main"),
+		makeEnv(projectID, "fix", "development", "inactive", "main"),
+	})
+
+	f := newCommandFactory(t, apiServer.URL, authServer.URL)
+
+	s := f.RunInteractive("env:delete", "-p", projectID, "dev")
+	s.Expect("Deleting it will delete all associated data.")
+	s.Expect("Are you sure you want to delete this environment? [Y/n]")
+	s.SendLine("n")
+	s.Expect("No environments to delete.")
+	assert.Error(t, s.Wait())
+
+	s = f.RunInteractive("env:delete", "-p", projectID, "fix")
+	s.Expect("Are you sure you want to delete the inactive environment")
+	s.SendLine("n")
+	s.Expect("No environments to delete.")
+	assert.Error(t, s.Wait())
+
+	s = f.RunInteractive("env:delete", "-p", projectID, "dev", "fix")
+	s.Expect("Selected environments: dev, fix")
+	s.Expect("Are you sure you want to delete this environment? [Y/n]")
+	s.SendLine("n")
+	s.Expect("Are you sure you want to delete the inactive environment")
+	s.SendLine("n")
+	s.Expect("No environments to delete.")
+	assert.Error(t, s.Wait())
+
+	assertTrimmed(t, `
+ID	Status
+main	Active
+dev	Active
+fix	Inactive
+`, f.Run("env:list", "-p", projectID, "--format", "plain", "--columns", "id,status"))
+}
@@ -1,7 +1,6 @@
 package tests
 
 import (
-	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -9,36 +8,16 @@ import (
 )
 
 func TestEnvironmentDeploy(t *testing.T) {
-	authServer := mockapi.NewAuthServer(t)
-	defer authServer.Close()
+	t.Parallel()
+	s := newScenario(t).WithEnv("main", "production", "active", nil, envActivities, envDeploy)
+	projectID := s.ProjectID
 
-	apiHandler := mockapi.NewHandler(t)
-	apiServer := httptest.NewServer(apiHandler)
-	defer apiServer.Close()
-
-	projectID := mockapi.ProjectID()
-	apiHandler.SetProjects([]*mockapi.Project{
-		{
-			ID: projectID,
-			Links: mockapi.MakeHALLinks(
-				"self=/projects/"+projectID,
-				"environments=/projects/"+projectID+"/environments",
-			),
-			DefaultBranch: "main",
-		},
-	})
-	main := makeEnv(projectID, "main", "production", "active", nil)
-	main.Links["#activities"] = mockapi.HALLink{HREF: "/projects/" + projectID + "/environments/main/activities"}
-	main.Links["#deploy"] = mockapi.HALLink{HREF: "/projects/" + projectID + "/environments/main/deploy"}
-	apiHandler.SetEnvironments([]*mockapi.Environment{main})
-
-	f := newCommandFactory(t, apiServer.URL, authServer.URL)
-	f.Run("cc")
+	f := s.Factory()
 
 	created1, _ := time.Parse(time.RFC3339, "2014-04-01T10:00:00Z")
 	created2, _ := time.Parse(time.RFC3339, "2014-04-02T10:00:00Z")
 	updated, _ := time.Parse(time.RFC3339, "2014-04-02T11:00:00Z")
-	apiHandler.SetProjectActivities(projectID, []*mockapi.Activity{
+	s.Handler.SetProjectActivities(projectID, []*mockapi.Activity{
 		{
 			ID:                "act1",
 			Type:              "environment.push",
@@ -9,6 +9,7 @@ import (
 )
 
 func TestEnvironmentDeployType(t *testing.T) {
+	t.Parallel()
 	authServer := mockapi.NewAuthServer(t)
 	defer authServer.Close()
 
@@ -33,7 +34,6 @@ func TestEnvironmentDeployType(t *testing.T) {
 	apiHandler.SetEnvironments([]*mockapi.Environment{main, makeEnv(projectID, "dev", "development", "inactive", nil)})
 
 	f := newCommandFactory(t, apiServer.URL, authServer.URL)
-	f.Run("cc")
 
 	expectedStderrPrefix := "Selected project: " + projectID + "\nSelected environment: main (type: production)\n\n"
 
@@ -75,3 +75,61 @@ func TestEnvironmentDeployType(t *testing.T) {
 	assert.Equal(t, "Selected project: "+projectID+"\nSelected environment: dev (type: development)\n\n"+
 		"The manual deployment type is not available as the environment is not active.\n", stdErr)
 }
+
+func TestEnvironmentDeployTypeInteractive(t *testing.T) {
+	t.Parallel()
+	authServer := mockapi.NewAuthServer(t)
+	defer authServer.Close()
+
+	apiHandler := mockapi.NewHandler(t)
+	apiServer := httptest.NewServer(apiHandler)
+	defer apiServer.Close()
+
+	projectID := mockapi.ProjectID()
+	apiHandler.SetProjects([]*mockapi.Project{
+		{
+			ID: projectID,
+			Links: mockapi.MakeHALLinks(
+				"self=/projects/"+projectID,
+				"environments=/projects/"+projectID+"/environments",
+			),
+			DefaultBranch: "main",
+		},
+	})
+	main := makeEnv(projectID, "main", "production", "active", nil)
+	main.SetSetting("enable_manual_deployments", true)
+	main.Links["#deploy"] = mockapi.HALLink{HREF: "/projects/" + projectID + "/environments/main/deploy"}
+	apiHandler.SetEnvironments([]*mockapi.Environment{main})
+	apiHandler.SetProjectActivities(projectID, []*mockapi.Activity{
+		{
+			ID:                "act1",
+			Type:              "environment.push",
+			State:             "staged",
+			Result:            "success",
+			CompletionPercent: 100,
+			Project:           projectID,
+			Environments:      []string{"main"},
+			Description:       "<user>Mock User</user> pushed to <environment>main</environment>",
+			Text:              "Mock User pushed to main",
+		},
+	})
+
+	f := newCommandFactory(t, apiServer.URL, authServer.URL)
+
+	// Decline the confirmation: the deployment type should not change.
+	s := f.RunInteractive("env:deploy:type", "automatic", "-p", projectID, "-e", "main")
+	s.Expect("Updating this setting will immediately deploy staged changes.")
+	s.Expect("Are you sure you want to continue? [Y/n]")
+	s.SendLine("n")
+	assert.Error(t, s.Wait())
+	assert.NotContains(t, s.Transcript(), "The deployment type was updated successfully")
+
+	assertTrimmed(t, "manual", f.Run("env:deploy:type", "-p", projectID, "-e", "main", "--pipe"))
+
+	// Accept the confirmation.
+	s = f.RunInteractive("env:deploy:type", "automatic", "-p", projectID, "-e", "main")
+	s.Expect("Are you sure you want to continue? [Y/n]")
+	s.SendLine("y")
+	s.Expect("The deployment type was updated successfully to: automatic")
+	assert.NoError(t, s.Wait())
+}
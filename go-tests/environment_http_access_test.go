@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupHTTPAccessTest creates a scenario where the main environment has HTTP
+// access settings, which the dev environment inherits.
+func setupHTTPAccessTest(t *testing.T) *scenario {
+	return newScenario(t).
+		WithEnv("main", "production", "active", nil).
+		WithEnv("dev", "development", "active", "main").
+		WithHTTPAccess("main", &httpAccess{
+			Addresses: []httpAccessAddress{
+				{Permission: "allow", Address: "192.0.2.0/24"},
+				{Permission: "deny", Address: "0.0.0.0/0"},
+			},
+			BasicAuth: map[string]string{"admin": "secret123"},
+			IsEnabled: true,
+		})
+}
+
+func TestEnvironmentHTTPAccess(t *testing.T) {
+	t.Parallel()
+	s := setupHTTPAccessTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	// Passwords are hidden.
+	stdOut, stdErr, err := f.RunCombinedOutput("httpaccess", "-p", p, "-e", "main")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "HTTP access settings for the environment main:")
+	assert.Equal(t, `addresses:
+    - { permission: allow, address: 192.0.2.0/24 }
+    - { permission: deny, address: 0.0.0.0/0 }
+basic_auth:
+    admin: '******'
+is_enabled: true
+`, stdOut)
+
+	// Settings are inherited from the parent environment.
+	assert.Equal(t, stdOut, f.Run("httpaccess", "-p", p, "-e", "dev"))
+
+	// Credentials replace the existing ones.
+	stdOut, stdErr, err = f.RunCombinedOutput("httpaccess", "-p", p, "-e", "dev", "--auth", "alice:password1", "--auth", "bob:password2")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Updated HTTP access settings for the environment dev:")
+	assert.Contains(t, stdErr, "The remote environment(s) must be redeployed for the change to take effect.")
+	assert.Contains(t, stdOut, "basic_auth:\n    alice: '******'\n    bob: '******'\n")
+	assert.NotContains(t, stdOut, "admin")
+	assert.Contains(t, stdOut, "192.0.2.0/24")
+	s.Recorder.AssertOneJSON(t, "PATCH", "/projects/"+p+"/environments/dev",
+		`{"http_access": {"basic_auth": {"alice": "password1", "bob": "password2"}}}`)
+	assert.Equal(t, map[string]string{"alice": "password1", "bob": "password2"}, s.HTTPAccess("dev").BasicAuth)
+	assert.Equal(t, map[string]string{"admin": "secret123"}, s.HTTPAccess("main").BasicAuth)
+
+	// Addresses are normalized to CIDRs.
+	s.Recorder.Reset()
+	stdOut, _, err = f.RunCombinedOutput("httpaccess", "-p", p, "-e", "dev", "--access", "allow:198.51.100.7", "--access", "allow:2001:db8::/32", "--access", "deny:any")
+	require.NoError(t, err)
+	assert.Contains(t, stdOut, "- { permission: allow, address: 198.51.100.7/32 }")
+	s.Recorder.AssertOneJSON(t, "PATCH", "/environments/dev", `{"http_access": {"addresses": [
+		{"permission": "allow", "address": "198.51.100.7/32"},
+		{"permission": "allow", "address": "2001:db8::/32"},
+		{"permission": "deny", "address": "0.0.0.0/0"}
+	]}}`)
+
+	s.Recorder.Reset()
+	_, _, err = f.RunCombinedOutput("httpaccess", "-p", p, "-e", "dev", "--enabled", "0")
+	require.NoError(t, err)
+	s.Recorder.AssertOneJSON(t, "PATCH", "/environments/dev", `{"http_access": {"is_enabled": false}}`)
+	assert.Contains(t, f.Run("httpaccess", "-p", p, "-e", "dev"), "is_enabled: false")
+
+	// Credentials and addresses can be cleared with 0.
+	s.Recorder.Reset()
+	stdOut, _, err = f.RunCombinedOutput("httpaccess", "-p", p, "-e", "dev", "--auth", "0", "--access", "0", "--enabled", "1")
+	require.NoError(t, err)
+	s.Recorder.AssertOneJSON(t, "PATCH", "/environments/dev", `{"http_access": {"addresses": null, "basic_auth": null, "is_enabled": true}}`)
+	assert.Equal(t, "addresses: {  }\nbasic_auth: {  }\nis_enabled: true\n", stdOut)
+
+	// The parent's settings are unchanged.
+	assert.Contains(t, f.Run("httpaccess", "-p", p, "-e", "main"), "admin: '******'")
+}
+
+func TestEnvironmentHTTPAccessInvalid(t *testing.T) {
+	t.Parallel()
+	s := setupHTTPAccessTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	for _, c := range []struct {
+		args   []string
+		stdErr string
+	}{
+		{[]string{"--access", "allow:10.0.0.0/33"}, `The address "10.0.0.0/33" is not a valid IPv4 address or CIDR`},
+		{[]string{"--access", "allow:2001:db8::/129"}, `The address "2001:db8::/129" is not a valid IPv6 address or CIDR`},
+		{[]string{"--access", "allow:192.0.2.300"}, `The address "192.0.2.300" is not a valid IP address or CIDR`},
+		{[]string{"--access", "permit:192.0.2.1"}, `The permission type 'permit' is not valid; it must be one of 'allow' or 'deny'`},
+		{[]string{"--access", "everyone"}, `Access "everyone" is not valid, please use the format: permission:address`},
+		{[]string{"--auth", "alice"}, `Auth "alice" is not valid. The format should be username:password`},
+		{[]string{"--auth", "a:password1"}, `The username "a" for --auth is not valid`},
+		{[]string{"--auth", "alice:short"}, `The minimum password length for --auth is 6 characters`},
+		{[]string{"--auth", "alice:password1", "--auth", "alice:password2"}, `The username "alice" is specified more than once for --auth`},
+	} {
+		_, stdErr, err := f.RunCombinedOutput(append([]string{"httpaccess", "-p", p, "-e", "dev"}, c.args...)...)
+		assert.Error(t, err, c.args)
+		assert.Contains(t, stdErr, c.stdErr)
+	}
+	s.Recorder.AssertNone(t, "PATCH", "/environments/dev")
+}
@@ -9,6 +9,7 @@ import (
 )
 
 func TestEnvironmentInfo(t *testing.T) {
+	t.Parallel()
 	authServer := mockapi.NewAuthServer(t)
 	defer authServer.Close()
 
@@ -39,23 +40,8 @@ func TestEnvironmentInfo(t *testing.T) {
 
 	f := newCommandFactory(t, apiServer.URL, authServer.URL)
 
-	// TODO disable the cache?
-	f.Run("cc")
-
-	assertTrimmed(t, `
-Property	Value
-id	main
-name	main
-machine_name	main-xyz
-title	Main
-type	production
-status	active
-parent	null
-project	`+projectID+`
-created_at	2014-04-01T10:00:00+00:00
-updated_at	2014-04-01T11:00:00+00:00
-deployment_type	manual
-`, f.Run("env:info", "-p", projectID, "-e", ".", "--format", "plain", "--refresh", "-vvv"))
+	f.Mask(projectID, "{{project_id}}")
+	f.RunGolden("env-info", "env:info", "-p", projectID, "-e", ".", "--format", "plain", "--refresh", "-vvv")
 
 	assert.Equal(t, "2014-04-01\n", f.Run("env:info", "-p", projectID, "-e", ".", "created_at", "--date-fmt", "Y-m-d"))
 
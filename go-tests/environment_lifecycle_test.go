@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupLifecycleTest creates a scenario with environments in each status,
+// served by the lifecycle stand-in, and simulated activities for each
+// operation on them.
+func setupLifecycleTest(t *testing.T) *scenario {
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil, envActivities).
+		WithEnv("dev", "development", "active", "main", envActivities).
+		WithEnv("staging", "staging", "paused", "main", envActivities).
+		WithEnv("fix", "development", "inactive", "main", envActivities).
+		WithEnv("feature", "development", "inactive", "main", envActivities).
+		WithActivitySimulator().
+		WithEnvLifecycle()
+	for _, env := range []string{"main", "dev", "staging", "fix", "feature"} {
+		for op, verb := range map[string]string{
+			"activate":   "activated",
+			"pause":      "paused",
+			"resume":     "resumed",
+			"deactivate": "deactivated",
+		} {
+			s.Sim.On("POST", `/environments/`+env+`/`+op+`$`, activitySpec{
+				Type:        "environment." + op,
+				Description: "<user>Mock User</user> " + verb + " environment <environment>" + env + "</environment>",
+				Steps:       activitySucceeds("Running " + op + " on " + env),
+			})
+		}
+	}
+	return s
+}
+
+func TestEnvironmentActivate(t *testing.T) {
+	t.Parallel()
+	s := setupLifecycleTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	// Several environments can be activated at once.
+	_, stdErr, err := f.RunCombinedOutput("env:activate", "-p", p, "fix", "feature")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Are you sure you want to activate the environment fix (type: development)? [Y/n] y")
+	assert.Contains(t, stdErr, "Are you sure you want to activate the environment feature (type: development)? [Y/n] y")
+	assert.Contains(t, stdErr, "Activating environment fix")
+	assert.Contains(t, stdErr, "Activating environment feature")
+	assert.Contains(t, stdErr, "Running activate on fix")
+	assert.Contains(t, stdErr, "The activity succeeded: [sim1] Mock User activated environment")
+	assert.Contains(t, stdErr, "The activity succeeded: [sim2] Mock User activated environment")
+	s.Recorder.AssertCount(t, 1, "POST", "/environments/fix/activate")
+	s.Recorder.AssertCount(t, 1, "POST", "/environments/feature/activate")
+
+	assertTrimmed(t, `
+ID	Status
+main	Active
+dev	Active
+feature	Active
+fix	Active
+staging	Paused
+`, f.Run("env:list", "-p", p, "--format", "plain", "--columns", "id,status", "--no-inactive"))
+
+	_, stdErr, err = f.RunCombinedOutput("env:activate", "-p", p, "dev")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "The environment dev (type: development) is already active.")
+	s.Recorder.AssertNone(t, "POST", "/environments/dev/activate")
+
+	// Paused environments are not resumed in non-interactive mode.
+	_, stdErr, err = f.RunCombinedOutput("env:activate", "-p", p, "staging")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "The environment staging (type: staging) is paused.")
+	assert.Contains(t, stdErr, "To resume the environment, run: platform-test env:resume")
+	s.Recorder.AssertNone(t, "POST", "/environments/staging/activate")
+	s.Recorder.AssertNone(t, "POST", "/environments/staging/resume")
+	assertTrimmed(t, "paused", f.Run("env:info", "-p", p, "-e", "staging", "status"))
+
+	// They can be resumed on demand.
+	session := f.RunInteractive("env:activate", "-p", p, "staging")
+	session.Expect("Do you want to resume it? [Y/n]")
+	session.SendLine("y")
+	assert.NoError(t, session.Wait())
+	assert.Contains(t, session.Transcript(), "The activity succeeded: [sim3] Mock User resumed environment staging")
+	s.Recorder.AssertCount(t, 1, "POST", "/environments/staging/resume")
+	s.Recorder.AssertNone(t, "POST", "/environments/staging/activate")
+	assertTrimmed(t, "active", f.Run("env:info", "-p", p, "-e", "staging", "status"))
+}
+
+func TestEnvironmentPauseResume(t *testing.T) {
+	t.Parallel()
+	s := setupLifecycleTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	_, stdErr, err := f.RunCombinedOutput("env:pause", "-p", p, "-e", "dev")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Pausing an environment helps to reduce resource consumption and carbon emissions.")
+	assert.Contains(t, stdErr, "Are you sure you want to pause the environment dev? [Y/n] y")
+	assert.Contains(t, stdErr, "Running pause on dev")
+	assert.Contains(t, stdErr, "The activity succeeded: [sim1] Mock User paused environment dev")
+	assertTrimmed(t, "paused", f.Run("env:info", "-p", p, "-e", "dev", "status"))
+
+	_, stdErr, err = f.RunCombinedOutput("env:pause", "-p", p, "-e", "dev")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "The environment dev (type: development) is already paused.")
+	s.Recorder.AssertCount(t, 1, "POST", "/environments/dev/pause")
+
+	_, stdErr, err = f.RunCombinedOutput("env:resume", "-p", p, "-e", "dev")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Are you sure you want to resume the paused environment dev? [Y/n] y")
+	assert.Contains(t, stdErr, "The activity succeeded: [sim2] Mock User resumed environment dev")
+	assertTrimmed(t, "active", f.Run("env:info", "-p", p, "-e", "dev", "status"))
+
+	_, stdErr, err = f.RunCombinedOutput("env:resume", "-p", p, "-e", "dev")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The environment dev (type: development) is not paused. Only paused environments can be resumed.")
+	s.Recorder.AssertCount(t, 1, "POST", "/environments/dev/resume")
+
+	// The default branch and inactive environments cannot be paused.
+	_, stdErr, err = f.RunCombinedOutput("env:pause", "-p", p, "-e", "main")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Operation not available: The environment main (type: production) can't be paused.")
+
+	_, stdErr, err = f.RunCombinedOutput("env:pause", "-p", p, "-e", "fix")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "can't be paused.")
+	assert.Contains(t, stdErr, "The environment is not active.")
+	s.Recorder.AssertNone(t, "POST", "/environments/main/pause")
+	s.Recorder.AssertNone(t, "POST", "/environments/fix/pause")
+}
+
+func TestEnvironmentDeactivate(t *testing.T) {
+	t.Parallel()
+	s := setupLifecycleTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	_, stdErr, err := f.RunCombinedOutput("environment:deactivate", "-p", p, "dev")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "The environment dev (type: development) is currently active.")
+	assert.Contains(t, stdErr, "Are you sure you want to delete this environment? [Y/n] y")
+	assert.Contains(t, stdErr, "Deleting environment dev")
+	assert.Contains(t, stdErr, "The activity succeeded: [sim1] Mock User deactivated environment dev")
+	assertTrimmed(t, "inactive", f.Run("env:info", "-p", p, "-e", "dev", "status"))
+
+	// Paused environments can be deactivated too.
+	_, _, err = f.RunCombinedOutput("environment:deactivate", "-p", p, "staging")
+	require.NoError(t, err)
+	s.Recorder.AssertCount(t, 1, "POST", "/environments/staging/deactivate")
+	assertTrimmed(t, "inactive", f.Run("env:info", "-p", p, "-e", "staging", "status"))
+
+	// Inactive environments are deleted.
+	_, stdErr, err = f.RunCombinedOutput("env:delete", "-p", p, "dev", "--delete-branch")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Deleting inactive environment dev")
+	s.Recorder.AssertCount(t, 1, "DELETE", "/projects/"+p+"/environments/dev")
+	assertTrimmed(t, `
+ID	Status
+main	Active
+feature	Inactive
+fix	Inactive
+staging	Inactive
+`, f.Run("env:list", "-p", p, "--format", "plain", "--columns", "id,status"))
+
+	// Environments with children are excluded by default.
+	_, stdErr, err = f.RunCombinedOutput("environment:deactivate", "-p", p, "main")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "1 environment excluded as it is has child environment(s).")
+	assert.Contains(t, stdErr, "No environments to delete.")
+
+	// The default branch cannot be deactivated: it has no #deactivate link.
+	_, stdErr, err = f.RunCombinedOutput("environment:deactivate", "-p", p, "main", "--allow-delete-parent")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Deleting environment main\nOperation not available: deactivate\n")
+	s.Recorder.AssertNone(t, "POST", "/environments/main/deactivate")
+	assertTrimmed(t, "active", f.Run("env:info", "-p", p, "-e", "main", "status"))
+}
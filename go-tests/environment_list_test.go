@@ -2,10 +2,7 @@ package tests
 
 import (
 	"net/http/httptest"
-	"net/url"
-	"strings"
 	"testing"
-	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -13,6 +10,7 @@ import (
 )
 
 func TestEnvironmentList(t *testing.T) {
+	t.Parallel()
 	authServer := mockapi.NewAuthServer(t)
 	defer authServer.Close()
 
@@ -68,25 +66,3 @@ dev	Dev	Active	development
 
 	assert.Equal(t, "fix\n", f.Run("environment:list", "-v", "-p", projectID, "--pipe", "--status=inactive"))
 }
-
-func makeEnv(projectID, name, envType, status string, parent any) *mockapi.Environment {
-	created, _ := time.Parse(time.RFC3339, "2014-04-01T10:00:00Z")
-	updated, _ := time.Parse(time.RFC3339, "2014-04-01T11:00:00Z")
-
-	return &mockapi.Environment{
-		ID:          name,
-		Name:        name,
-		MachineName: name + "-xyz",
-		Title:       strings.ToTitle(name[:1]) + name[1:],
-		Parent:      parent,
-		Type:        envType,
-		Status:      status,
-		Project:     projectID,
-		CreatedAt:   created,
-		UpdatedAt:   updated,
-		Links: mockapi.MakeHALLinks(
-			"self=/projects/"+url.PathEscape(projectID)+"/environments/"+url.PathEscape(name),
-			"#edit=/projects/"+url.PathEscape(projectID)+"/environments/"+url.PathEscape(name),
-		),
-	}
-}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvironmentPush(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil, envActivities).
+		WithGitRepository()
+	seedRev := s.Git.Seed(s.ProjectID, "main", map[string]string{"README.md": "# Test project\n"})
+	f := s.Clone()
+	dir := f.WorkDir()
+
+	// Push a new commit to the current environment.
+	writeFiles(t, dir, map[string]string{"index.html": "Hello world\n"})
+	runGit(t, dir, "add", "--all")
+	runGit(t, dir, "commit", "--quiet", "--message", "Add index page")
+	rev := runGit(t, dir, "rev-parse", "HEAD")
+
+	_, stdErr, err := f.RunCombinedOutput("environment:push")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Pushing HEAD to the environment")
+	assert.Equal(t, rev, s.Git.Branches(s.ProjectID)["main"])
+
+	pushes := s.Git.Pushes()
+	require.Len(t, pushes, 1)
+	assert.Equal(t, []gitRefUpdate{{Ref: "refs/heads/main", OldRev: seedRev, NewRev: rev}}, pushes[0].Updates)
+	assert.Empty(t, pushes[0].Options)
+
+	activities := s.Activities()
+	require.Len(t, activities, 1)
+	assert.Equal(t, "environment.push", activities[0].Type)
+	assert.Equal(t, []string{"main"}, activities[0].Environments)
+
+	// Push a new branch, creating an active environment.
+	runGit(t, dir, "checkout", "--quiet", "-b", "feature")
+	writeFiles(t, dir, map[string]string{"feature.txt": "New feature\n"})
+	runGit(t, dir, "add", "--all")
+	runGit(t, dir, "commit", "--quiet", "--message", "Add feature")
+
+	_, stdErr, err = f.RunCombinedOutput("environment:push", "--activate", "--parent", "main", "--type", "staging")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Pushing HEAD to the branch feature of project")
+	assert.Equal(t, runGit(t, dir, "rev-parse", "HEAD"), s.Git.Branches(s.ProjectID)["feature"])
+
+	pushes = s.Git.Pushes()
+	require.Len(t, pushes, 2)
+	assert.Equal(t, map[string]string{
+		"environment.status": "active",
+		"environment.parent": "main",
+		"environment.type":   "staging",
+	}, pushes[1].Options)
+
+	assertTrimmed(t, `
+ID	Status	Type
+main	Active	production
+feature	Active	staging
+`, f.Run("environment:list", "--format", "plain", "--columns", "id,status,type"))
+
+	activities = s.Activities()
+	require.Len(t, activities, 2)
+	assert.Equal(t, "environment.push", activities[1].Type)
+	assert.Equal(t, []string{"feature"}, activities[1].Environments)
+
+	// Pushing from outside a repository fails.
+	_, stdErr, err = s.Factory().RunCombinedOutput("environment:push", "-p", s.ProjectID, "-e", "main")
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "This command can only be run from inside a Git repository.")
+}
@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvironmentRedeploy(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil, envActivities, envRedeploy).
+		WithEnv("dev", "development", "active", "main", envActivities, envRedeploy).
+		WithEnv("staging", "staging", "active", "main", envActivities, envRedeploy).
+		WithEnv("old", "development", "inactive", "main").
+		WithActivitySimulator()
+	s.Sim.On("POST", `/environments/main/redeploy$`, activitySpec{
+		Type:        "environment.redeploy",
+		Description: "<user>Mock User</user> redeployed environment <environment>main</environment>",
+		Steps:       activitySucceeds("Redeploying environment main", "  Configuring apps"),
+	})
+	s.Sim.On("POST", `/environments/dev/redeploy$`, activitySpec{
+		Type:        "environment.redeploy",
+		Description: "<user>Mock User</user> redeployed environment <environment>dev</environment>",
+		Steps:       activityFails("Redeploying environment dev", "  E: Error building app"),
+	})
+	s.Sim.On("POST", `/environments/staging/redeploy$`, activitySpec{
+		Type:        "environment.redeploy",
+		Description: "<user>Mock User</user> redeployed environment <environment>staging</environment>",
+	})
+	f, p := s.Factory(), s.ProjectID
+
+	_, stdErr, err := f.RunCombinedOutput("redeploy", "-p", p, "-e", "main")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "Waiting for the activity: [sim1] Mock User redeployed environment main")
+	assert.Contains(t, stdErr, "Redeploying environment main\n  Configuring apps\n")
+	assert.Contains(t, stdErr, "The activity succeeded: [sim1] Mock User redeployed environment main")
+	assert.Equal(t, "complete", s.Sim.State("sim1"))
+	s.Recorder.AssertCount(t, 1, "POST", "/environments/main/redeploy")
+
+	_, stdErr, err = f.RunCombinedOutput("redeploy", "-p", p, "-e", "dev")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "E: Error building app")
+	assert.Contains(t, stdErr, "The activity failed: [sim2] Mock User redeployed environment dev")
+	assert.Equal(t, "complete", s.Sim.State("sim2"))
+
+	// The activity is not polled with --no-wait.
+	s.Recorder.Reset()
+	_, stdErr, err = f.RunCombinedOutput("redeploy", "-p", p, "-e", "staging", "--no-wait")
+	assert.NoError(t, err)
+	assert.NotContains(t, stdErr, "Waiting for the activity")
+	assert.Equal(t, "pending", s.Sim.State("sim3"))
+	s.Recorder.AssertNone(t, "GET", "/activities/sim3")
+
+	_, stdErr, err = f.RunCombinedOutput("redeploy", "-p", p, "-e", "old")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "can't be redeployed")
+	s.Recorder.AssertNone(t, "POST", "/environments/old/redeploy")
+}
@@ -0,0 +1,38 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvironmentSetRemote(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil).
+		WithEnv("dev", "development", "active", "main").
+		WithGitRepository()
+	s.Git.Seed(s.ProjectID, "main", map[string]string{"README.md": "# Test project\n"})
+	f := s.Clone()
+	dir := f.WorkDir()
+	runGit(t, dir, "checkout", "--quiet", "-b", "local-fix")
+
+	_, stdErr, err := f.RunCombinedOutput("environment:set-remote", "dev")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "The local branch local-fix is mapped to the remote environment dev")
+
+	projectConfig, err := os.ReadFile(filepath.Join(dir, ".platform", "local", "project.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(projectConfig), "local-fix: dev")
+
+	_, stdErr, err = f.RunCombinedOutput("environment:set-remote", "0")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "The local branch local-fix is not mapped to a remote environment")
+
+	_, stdErr, err = f.RunCombinedOutput("environment:set-remote", "missing")
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "Environment not found: missing")
+}
@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupSyncTest creates a scenario with environments which can be merged and
+// synchronized (dev and staging), and others which cannot: main has no
+// parent, fix is inactive, and feature's parent (fix) is inactive.
+func setupSyncTest(t *testing.T) *scenario {
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil, envActivities).
+		WithEnv("dev", "development", "active", "main", envActivities).
+		WithEnv("staging", "staging", "active", "main", envActivities).
+		WithEnv("fix", "development", "inactive", "main").
+		WithEnv("feature", "development", "active", "fix").
+		WithActivitySimulator().
+		WithMergeAndSync()
+	s.Sim.On("POST", `/environments/dev/merge$`, activitySpec{
+		Type:        "environment.merge",
+		Description: "<user>Mock User</user> merged <environment>dev</environment> into <environment>main</environment>",
+		Steps:       activitySucceeds("Merging dev into main"),
+	})
+	s.Sim.On("POST", `/environments/(dev|staging)/synchronize$`, activitySpec{
+		Type:        "environment.synchronize",
+		Description: "<user>Mock User</user> synced an environment from <environment>main</environment>",
+		Steps:       activitySucceeds("Synchronizing from main"),
+	})
+	return s
+}
+
+func TestEnvironmentMerge(t *testing.T) {
+	t.Parallel()
+	s := setupSyncTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	session := f.RunInteractive("merge", "-p", p, "-e", "dev")
+	session.Expect("Are you sure you want to merge dev into its parent, main? [Y/n]")
+	session.SendLine("n")
+	assert.Error(t, session.Wait())
+	s.Recorder.AssertNone(t, "POST", "/environments/dev/merge")
+
+	_, stdErr, err := f.RunCombinedOutput("merge", "-p", p, "-e", "dev")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Are you sure you want to merge dev into its parent, main? [Y/n] y")
+	assert.Contains(t, stdErr, "Merging dev into main")
+	assert.Contains(t, stdErr, "The activity succeeded: [sim1] Mock User merged dev into main")
+	s.Recorder.AssertCount(t, 1, "POST", "/projects/"+p+"/environments/dev/merge")
+
+	_, stdErr, err = f.RunCombinedOutput("merge", "-p", p, "-e", "main")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Operation not available: The environment main can't be merged.")
+	assert.Contains(t, stdErr, "The environment does not have a parent.")
+
+	_, stdErr, err = f.RunCombinedOutput("merge", "-p", p, "-e", "feature")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Operation not available: The environment feature can't be merged.")
+
+	_, stdErr, err = f.RunCombinedOutput("merge", "-p", p, "-e", "staging", "--resources-init", "child")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The --resources-init option cannot be used as the project does not support flexible resources.")
+	s.Recorder.AssertNone(t, "POST", "/environments/main/merge")
+	s.Recorder.AssertNone(t, "POST", "/environments/feature/merge")
+	s.Recorder.AssertNone(t, "POST", "/environments/staging/merge")
+}
+
+func TestEnvironmentSynchronize(t *testing.T) {
+	t.Parallel()
+	s := setupSyncTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	_, stdErr, err := f.RunCombinedOutput("sync", "-p", p, "-e", "dev", "data")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Are you sure you want to synchronize data from main to dev? [Y/n] y")
+	assert.Contains(t, stdErr, "Synchronizing environment dev")
+	assert.Contains(t, stdErr, "The activity succeeded: [sim1] Mock User synced an environment from main")
+	s.Recorder.AssertOneJSON(t, "POST", "/projects/"+p+"/environments/dev/synchronize",
+		`{"synchronize_code": false, "synchronize_data": true, "rebase": false}`)
+
+	s.Recorder.Reset()
+	_, stdErr, err = f.RunCombinedOutput("sync", "-p", p, "-e", "dev", "code", "data", "--rebase")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Are you sure you want to synchronize code and data from main to dev? [Y/n] y")
+	s.Recorder.AssertOneJSON(t, "POST", "/environments/dev/synchronize",
+		`{"synchronize_code": true, "synchronize_data": true, "rebase": true}`)
+
+	s.Recorder.Reset()
+	_, _, err = f.RunCombinedOutput("sync", "-p", p, "-e", "dev", "both")
+	require.NoError(t, err)
+	s.Recorder.AssertOneJSON(t, "POST", "/environments/dev/synchronize",
+		`{"synchronize_code": true, "synchronize_data": true, "rebase": false}`)
+
+	// Validation errors from the API are translated.
+	s.Recorder.Reset()
+	_, stdErr, err = f.RunCombinedOutput("sync", "-p", p, "-e", "dev", "data", "--rebase")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "you specified the --rebase option, but this only applies to synchronizing code, which you have not selected.")
+	assert.Contains(t, stdErr, "Error:: Rebasing is only possible when synchronizing code.")
+	s.Recorder.AssertCount(t, 1, "POST", "/environments/dev/synchronize")
+
+	// Nothing is sent if the confirmation is declined, or if an option is invalid.
+	s.Recorder.Reset()
+	_, stdErr, err = f.RunCombinedOutput("sync", "-p", p, "-e", "dev", "data", "--no")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Are you sure you want to synchronize data from main to dev? [Y/n] n")
+
+	_, stdErr, err = f.RunCombinedOutput("sync", "-p", p, "-e", "dev", "resources")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Invalid value: resources (it must be one of: code, data or both).")
+	s.Recorder.AssertNone(t, "POST", "/environments/dev/synchronize")
+
+	// Options are chosen interactively if none are given.
+	session := f.RunInteractive("sync", "-p", p, "-e", "staging")
+	session.Expect("Do you want to synchronize code from main to staging? [y/N]")
+	session.SendLine("n")
+	session.Expect("Do you want to synchronize data from main to staging? [y/N]")
+	session.SendLine("y")
+	assert.NoError(t, session.Wait())
+	s.Recorder.AssertOneJSON(t, "POST", "/environments/staging/synchronize",
+		`{"synchronize_code": false, "synchronize_data": true, "rebase": false}`)
+
+	session = f.RunInteractive("sync", "-p", p, "-e", "staging")
+	session.Expect("Do you want to synchronize code from main to staging? [y/N]")
+	session.SendLine("n")
+	session.Expect("Do you want to synchronize data from main to staging? [y/N]")
+	session.SendLine("n")
+	session.Expect("You did not select anything to synchronize.")
+	assert.Error(t, session.Wait())
+	s.Recorder.AssertCount(t, 1, "POST", "/environments/staging/synchronize")
+}
+
+func TestEnvironmentSynchronizeUnavailable(t *testing.T) {
+	t.Parallel()
+	s := setupSyncTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	_, stdErr, err := f.RunCombinedOutput("sync", "-p", p, "-e", "main", "data")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Operation not available: The environment main can't be synchronized.")
+	assert.Contains(t, stdErr, "The environment does not have a parent.")
+
+	_, stdErr, err = f.RunCombinedOutput("sync", "-p", p, "-e", "fix", "data")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The environment is not active.")
+
+	_, stdErr, err = f.RunCombinedOutput("sync", "-p", p, "-e", "feature", "data")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The parent environment fix is not active.")
+	s.Recorder.AssertNone(t, "POST", "/synchronize")
+}
+
+func TestEnvironmentSynchronizeResources(t *testing.T) {
+	t.Parallel()
+	s := setupSyncTest(t).WithSizingAPI()
+	f, p := s.Factory(), s.ProjectID
+
+	_, stdErr, err := f.RunCombinedOutput("sync", "-p", p, "-e", "dev", "code", "resources")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Are you sure you want to synchronize code and resources from main to dev? [Y/n] y")
+	s.Recorder.AssertOneJSON(t, "POST", "/environments/dev/synchronize",
+		`{"synchronize_code": true, "synchronize_data": false, "synchronize_resources": true, "rebase": false}`)
+
+	_, stdErr, err = f.RunCombinedOutput("sync", "-p", p, "-e", "dev", "both")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Invalid value: both (it must be one of: code, data or resources).")
+
+	_, _, err = f.RunCombinedOutput("merge", "-p", p, "-e", "dev", "--resources-init", "child")
+	require.NoError(t, err)
+	s.Recorder.AssertOneJSON(t, "POST", "/environments/dev/merge", `{"resources": {"init": "child"}}`)
+}
@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// primaryMockRoutes returns the routes from mockRoutes, with only the HTTPS
+// route marked as primary (as in a real deployment).
+func primaryMockRoutes() map[string]any {
+	routes := mockRoutes()
+	routes["http://main.example.com/"].(map[string]any)["primary"] = false
+	return routes
+}
+
+func TestEnvironmentURL(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil).
+		WithEnv("dev", "development", "active", "main").
+		WithRoutes("main", primaryMockRoutes()).
+		WithRoutes("dev", map[string]any{})
+	f, p := s.Factory(), s.ProjectID
+
+	// URLs are read from the current deployment, the primary route first.
+	assert.Equal(t, "https://main.example.com/\n", f.Run("url", "-p", p, "-e", "main", "--primary", "--pipe"))
+	assert.Equal(t, "https://main.example.com/\nhttp://main.example.com/\n", f.Run("url", "-p", p, "-e", "main", "--pipe"))
+	assert.Equal(t, "https://main.example.com/\nhttp://main.example.com/\n", f.Run("url", "-p", p, "-e", "main", "--browser", "0"))
+
+	// Without a display, only the first URL is printed in non-interactive mode.
+	assert.Equal(t, "https://main.example.com/\n", f.WithExtraEnv("DISPLAY=none").Run("url", "-p", p, "-e", "main"))
+
+	stdOut, _, err := f.RunCombinedOutput("url", "-p", p, "-e", "dev", "--pipe")
+	assertExitCode(t, 1, err)
+	assert.Equal(t, "No URLs found.\n", stdOut)
+}
+
+func TestEnvironmentURLLocal(t *testing.T) {
+	t.Parallel()
+	f := &cmdFactory{t: t}
+	routes, err := json.Marshal(primaryMockRoutes())
+	require.NoError(t, err)
+	f.extraEnv = []string{"PLATFORM_ROUTES=" + base64.StdEncoding.EncodeToString(routes)}
+
+	assert.Equal(t, "https://main.example.com/\n", f.Run("url", "--primary", "--pipe"))
+	assert.Equal(t, "https://main.example.com/\nhttp://main.example.com/\n", f.Run("url", "--pipe"))
+
+	// Without a primary route, --primary fails.
+	noPrimary := mockRoutes()
+	for _, route := range noPrimary {
+		route.(map[string]any)["primary"] = false
+	}
+	routes, err = json.Marshal(noPrimary)
+	require.NoError(t, err)
+	f.extraEnv = []string{"PLATFORM_ROUTES=" + base64.StdEncoding.EncodeToString(routes)}
+	_, stdErr, err := f.RunCombinedOutput("url", "--primary", "--pipe")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "No primary route found.")
+}
+
+// TestEnvironmentCurl checks that environment:curl sends requests to the
+// environment's URL in the API, with the access token. The command does not
+// request the environment's site, so HTTP access credentials are not used.
+func TestEnvironmentCurl(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil).
+		WithEnv("dev", "development", "active", "main").
+		Use(standIn(func(r chi.Router) {
+			r.HandleFunc("/projects/{project}/environments/{environment}/echo", func(w http.ResponseWriter, req *http.Request) {
+				body, _ := io.ReadAll(req.Body)
+				_, _ = io.WriteString(w, strings.Join([]string{
+					req.Method, chi.URLParam(req, "environment"), req.Header.Get("X-Test"), string(body),
+				}, " "))
+			})
+		}))
+	f, p := s.Factory(), s.ProjectID
+
+	assert.Contains(t, f.Run("environment:curl", "-p", p, "-e", "dev"), `"name":"dev"`)
+
+	assert.Equal(t, "PUT dev yes a=b", f.Run("environment:curl", "-p", p, "-e", "dev", "/echo", "-X", "PUT", "-H", "X-Test: yes", "-d", "a=b"))
+	req := s.Recorder.RequireOne(t, "PUT", "/projects/"+p+"/environments/dev/echo")
+	assert.True(t, strings.HasPrefix(req.Header.Get("Authorization"), "Bearer "), "Authorization header: %s", req.Header.Get("Authorization"))
+
+	assert.Equal(t, `POST main  {"a":1}`, f.Run("environment:curl", "-p", p, "-e", "main", "echo", "--json", `{"a":1}`))
+	req = s.Recorder.RequireOne(t, "POST", "/projects/"+p+"/environments/main/echo")
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.Equal(t, "application/json", req.Header.Get("Accept"))
+
+	// Errors fail with the response body, or without it if --fail is set.
+	stdOut, _, err := f.RunCombinedOutput("environment:curl", "-p", p, "-e", "main", "/nonexistent")
+	assertExitCode(t, 22, err)
+	assert.NotEmpty(t, stdOut)
+	stdOut, _, err = f.RunCombinedOutput("environment:curl", "-p", p, "-e", "main", "/nonexistent", "--fail")
+	assertExitCode(t, 22, err)
+	assert.Empty(t, stdOut)
+
+	// Paths cannot point to another host.
+	s.Recorder.Reset()
+	_, stdErr, err := f.RunCombinedOutput("environment:curl", "-p", p, "-e", "main", "https://example.com/echo")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Invalid path: https://example.com/echo")
+	s.Recorder.AssertNone(t, "GET", "/echo")
+}
@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/platformsh/cli/pkg/mockapi"
+)
+
+// envSyncAPI stands in for merging and synchronizing environments. It checks
+// the scenario's environments, so it has no state of its own.
+type envSyncAPI struct {
+	s *scenario
+}
+
+// projectSettingsAPI serves the project's settings, which the mock API lacks.
+type projectSettingsAPI struct {
+	mu       sync.Mutex
+	settings map[string]any
+}
+
+// WithMergeAndSync serves merging environments into their parents, and
+// synchronizing them from their parents, from an envSyncAPI.
+//
+// Environments have the #merge and #synchronize links when they are active
+// and have an active parent. The stand-in checks the same, and validates the
+// synchronize options. Neither operation changes anything, and no activities
+// are returned: tests can start them with the activity simulator (see
+// WithActivitySimulator), which must be added first.
+func (s *scenario) WithMergeAndSync() *scenario {
+	if s.envSync != nil {
+		return s
+	}
+	s.envSync = &envSyncAPI{s: s}
+	return s.Use(standIn(s.envSync.routes))
+}
+
+func (api *envSyncAPI) routes(r chi.Router) {
+	r.Post("/projects/{project}/environments/{environment}/merge", api.handleMerge)
+	r.Post("/projects/{project}/environments/{environment}/synchronize", api.handleSynchronize)
+}
+
+// WithSizingAPI enables the flexible resources (sizing) API in the CLI's
+// configuration, and in the project's settings.
+func (s *scenario) WithSizingAPI() *scenario {
+	s.serveSettings().set("sizing_api_enabled", true)
+	return s
+}
+
+// sizingAPIEnabled checks if the sizing API is enabled (see WithSizingAPI).
+func (s *scenario) sizingAPIEnabled() bool {
+	return s.settings != nil && s.settings.get("sizing_api_enabled") == true
+}
+
+// serveSettings serves the project's settings from a projectSettingsAPI
+// (once).
+func (s *scenario) serveSettings() *projectSettingsAPI {
+	if s.settings == nil {
+		s.settings = &projectSettingsAPI{settings: map[string]any{}}
+		s.Use(standIn(func(r chi.Router) {
+			r.Get("/projects/{project}/settings", s.settings.handleGet)
+		}))
+	}
+	return s.settings
+}
+
+func (api *projectSettingsAPI) get(name string) any {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.settings[name]
+}
+
+func (api *projectSettingsAPI) set(name string, value any) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.settings[name] = value
+}
+
+func (api *projectSettingsAPI) handleGet(w http.ResponseWriter, _ *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	writeJSON(w, http.StatusOK, api.settings)
+}
+
+// syncError returns the reason why an environment cannot be merged or
+// synchronized, or an empty string if it can.
+func (api *envSyncAPI) syncError(env *mockapi.Environment) string {
+	parentName, _ := env.Parent.(string)
+	if parentName == "" {
+		return "The environment does not have a parent."
+	}
+	if env.Status != "active" {
+		return "The environment is not active."
+	}
+	if parent := api.s.findEnv(parentName); parent == nil || parent.Status != "active" {
+		return "The parent environment is not active."
+	}
+	return ""
+}
+
+// applyLinks sets the #merge and #synchronize links of each environment which
+// can be merged and synchronized. The scenario's lock must be held.
+func (api *envSyncAPI) applyLinks() {
+	for _, env := range api.s.envs {
+		base := "/projects/" + url.PathEscape(api.s.ProjectID) + "/environments/" + url.PathEscape(env.Name)
+		for _, op := range []string{"merge", "synchronize"} {
+			if api.syncError(env) == "" {
+				env.Links["#"+op] = mockapi.HALLink{HREF: base + "/" + op}
+			} else {
+				delete(env.Links, "#"+op)
+			}
+		}
+	}
+}
+
+// syncEnv returns the environment in the request's path, or writes an error
+// response if it cannot be merged or synchronized.
+func (api *envSyncAPI) syncEnv(w http.ResponseWriter, req *http.Request) *mockapi.Environment {
+	env := api.s.findEnv(chi.URLParam(req, "environment"))
+	if env == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Environment not found"})
+		return nil
+	}
+	if msg := api.syncError(env); msg != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"detail": map[string]any{"error": msg}})
+		return nil
+	}
+	return env
+}
+
+func (api *envSyncAPI) handleMerge(w http.ResponseWriter, req *http.Request) {
+	api.s.mu.Lock()
+	defer api.s.mu.Unlock()
+	if api.syncEnv(w, req) == nil {
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"_embedded": map[string]any{"activities": []any{}}})
+}
+
+// handleSynchronize checks that something is synchronized, that rebasing is
+// only requested with code, and that resources are only synchronized if the
+// sizing API is enabled.
+func (api *envSyncAPI) handleSynchronize(w http.ResponseWriter, req *http.Request) {
+	var params struct {
+		Code      bool `json:"synchronize_code"`
+		Data      bool `json:"synchronize_data"`
+		Resources bool `json:"synchronize_resources"`
+		Rebase    bool `json:"rebase"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": "Invalid synchronize parameters"})
+		return
+	}
+	sizing := api.s.sizingAPIEnabled()
+	api.s.mu.Lock()
+	defer api.s.mu.Unlock()
+	if api.syncEnv(w, req) == nil {
+		return
+	}
+	var msg string
+	switch {
+	case !params.Code && !params.Data && !params.Resources:
+		msg = "Nothing to synchronize."
+	case params.Rebase && !params.Code:
+		msg = "Rebasing is only possible when synchronizing code."
+	case params.Resources && !sizing:
+		msg = "Resources cannot be synchronized on this project."
+	}
+	if msg != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"detail": map[string]any{"error": msg}})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"_embedded": map[string]any{"activities": []any{}}})
+}
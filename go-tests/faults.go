@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// fault describes a way for the mock API to misbehave.
+type fault struct {
+	// Method is the HTTP method to match, or empty to match any method.
+	Method string
+	// Path is a regular expression matched against the URL path.
+	Path string
+
+	// Times limits the fault to the first N matching requests (0 means no limit).
+	Times int
+	// Probability makes the fault happen randomly, with a seeded random number
+	// generator so that results are repeatable (0 means always).
+	Probability float64
+
+	// Delay is how long to wait before responding (or failing).
+	Delay time.Duration
+	// Status is an HTTP error status code to respond with.
+	Status int
+	// RetryAfter sets the Retry-After header, e.g. on a 429 or 503 response.
+	RetryAfter string
+	// Body is the response body to send with the Status.
+	Body string
+	// DropConnection closes the connection without sending a response.
+	DropConnection bool
+	// Truncate sends only the first half of the real response body.
+	Truncate bool
+}
+
+type faultState struct {
+	fault
+	pattern *regexp.Regexp
+	hits    int
+}
+
+// faultInjector is an http.Handler middleware which applies faults to
+// matching requests, and passes other requests on to the next handler.
+type faultInjector struct {
+	next http.Handler
+
+	mu     sync.Mutex
+	faults []*faultState
+	rand   *rand.Rand
+}
+
+func newFaultInjector(next http.Handler, seed uint64) *faultInjector {
+	return &faultInjector{
+		next: next,
+		rand: rand.New(rand.NewPCG(seed, seed)), //nolint:gosec // Repeatable randomness is intended.
+	}
+}
+
+// Add registers a fault. Faults are checked in the order they are added.
+func (fi *faultInjector) Add(f fault) {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	fi.faults = append(fi.faults, &faultState{fault: f, pattern: regexp.MustCompile(f.Path)})
+}
+
+// Clear removes all faults.
+func (fi *faultInjector) Clear() {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	fi.faults = nil
+}
+
+// match finds the fault to apply to a request, if any.
+func (fi *faultInjector) match(req *http.Request) *fault {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	for _, f := range fi.faults {
+		if f.Method != "" && f.Method != req.Method {
+			continue
+		}
+		if !f.pattern.MatchString(req.URL.Path) {
+			continue
+		}
+		if f.Times > 0 && f.hits >= f.Times {
+			continue
+		}
+		if f.Probability > 0 && fi.rand.Float64() >= f.Probability {
+			continue
+		}
+		f.hits++
+		matched := f.fault
+		return &matched
+	}
+	return nil
+}
+
+func (fi *faultInjector) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f := fi.match(req)
+	if f == nil {
+		fi.next.ServeHTTP(w, req)
+		return
+	}
+
+	if f.Delay > 0 {
+		select {
+		case <-time.After(f.Delay):
+		case <-req.Context().Done():
+			return
+		}
+	}
+
+	switch {
+	case f.DropConnection:
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			panic("the response writer does not support hijacking")
+		}
+		conn, _, err := hj.Hijack()
+		if err == nil {
+			_ = conn.Close()
+		}
+	case f.Status != 0:
+		if f.RetryAfter != "" {
+			w.Header().Set("Retry-After", f.RetryAfter)
+		}
+		body := f.Body
+		if body == "" {
+			body = `{"status": "error", "code": ` + strconv.Itoa(f.Status) + `, "message": "` + http.StatusText(f.Status) + `"}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(f.Status)
+		_, _ = w.Write([]byte(body))
+	case f.Truncate:
+		rec := httptest.NewRecorder()
+		fi.next.ServeHTTP(rec, req)
+		for k, v := range rec.Header() {
+			if k != "Content-Length" {
+				w.Header()[k] = v
+			}
+		}
+		w.WriteHeader(rec.Code)
+		b := rec.Body.Bytes()
+		_, _ = w.Write(b[:len(b)/2])
+	default:
+		// A delay alone.
+		fi.next.ServeHTTP(w, req)
+	}
+}
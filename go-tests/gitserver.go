@@ -0,0 +1,270 @@
+package tests
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// zeroRev is the revision Git reports for a ref that does not exist (before
+// it is created, or after it is deleted).
+const zeroRev = "0000000000000000000000000000000000000000"
+
+// postReceiveHook records each push in a file which the gitServer reads after
+// the request: one "update <old> <new> <ref>" line per ref, and one
+// "option <value>" line per push option (e.g. environment.status=active).
+const postReceiveHook = `#!/bin/sh
+{
+	while read -r old new ref; do
+		echo "update $old $new $ref"
+	done
+	i=0
+	while [ "$i" -lt "${GIT_PUSH_OPTION_COUNT:-0}" ]; do
+		eval "echo \"option \$GIT_PUSH_OPTION_$i\""
+		i=$((i + 1))
+	done
+} >> "$GIT_DIR/pushes.log"
+`
+
+// gitRefUpdate is a change to a ref received in a push.
+type gitRefUpdate struct {
+	Ref    string
+	OldRev string
+	NewRev string
+}
+
+// Branch returns the branch name, if the ref is a branch.
+func (u gitRefUpdate) Branch() (string, bool) {
+	return strings.CutPrefix(u.Ref, "refs/heads/")
+}
+
+// Deleted reports whether the ref was deleted.
+func (u gitRefUpdate) Deleted() bool {
+	return u.NewRev == zeroRev
+}
+
+// gitPush is a push received by the gitServer.
+type gitPush struct {
+	Repo    string
+	Updates []gitRefUpdate
+	Options map[string]string
+}
+
+// gitServer serves bare Git repositories over the smart HTTP protocol, using
+// "git http-backend", so that the CLI can clone, fetch and push.
+//
+// Repositories are created with Init, and their URLs used as a project's
+// Repository.URL. The CLI does not treat the server as an SSH host, so no
+// certificate or SSH configuration is needed.
+type gitServer struct {
+	t      *testing.T
+	root   string
+	server *httptest.Server
+
+	mu     sync.Mutex
+	pushes []gitPush
+	onPush func(gitPush)
+}
+
+func newGitServer(t *testing.T) *gitServer {
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("skipping test: git is not installed")
+	}
+	g := &gitServer{t: t, root: t.TempDir()}
+	backend := &cgi.Handler{
+		Path: gitPath,
+		Args: []string{"http-backend"},
+		Env:  append(gitEnv(), "GIT_PROJECT_ROOT="+g.root, "GIT_HTTP_EXPORT_ALL=1"),
+	}
+	g.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/git-receive-pack") {
+			backend.ServeHTTP(w, req)
+			return
+		}
+		// Handle one push at a time, so that each reads its own log.
+		g.mu.Lock()
+		backend.ServeHTTP(w, req)
+		repo := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/"), "/git-receive-pack")
+		push, ok := g.readPushLog(repo)
+		if ok {
+			g.pushes = append(g.pushes, push)
+		}
+		onPush := g.onPush
+		g.mu.Unlock()
+		if ok && onPush != nil {
+			onPush(push)
+		}
+	}))
+	t.Cleanup(g.server.Close)
+	return g
+}
+
+// OnPush sets a function to call after each successful push.
+func (g *gitServer) OnPush(fn func(gitPush)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.onPush = fn
+}
+
+// Init creates an empty bare repository named "<name>.git" and returns its URL.
+func (g *gitServer) Init(name string) string {
+	dir := g.Dir(name)
+	runGit(g.t, "", "init", "--bare", "--quiet", "--initial-branch=main", dir)
+	runGit(g.t, dir, "config", "http.receivepack", "true")
+	runGit(g.t, dir, "config", "receive.advertisePushOptions", "true")
+	require.NoError(g.t, os.WriteFile(filepath.Join(dir, "hooks", "post-receive"), []byte(postReceiveHook), 0o755)) //nolint:gosec // The hook must be executable.
+	return g.URL(name)
+}
+
+// URL returns the clone URL of a repository.
+func (g *gitServer) URL(name string) string {
+	return g.server.URL + "/" + name + ".git"
+}
+
+// Dir returns the path to a bare repository.
+func (g *gitServer) Dir(name string) string {
+	return filepath.Join(g.root, name+".git")
+}
+
+// Seed commits files to a branch of a repository, without triggering OnPush.
+// It returns the new commit hash.
+func (g *gitServer) Seed(name, branch string, files map[string]string) string {
+	work := g.t.TempDir()
+	runGit(g.t, work, "init", "--quiet", "--initial-branch="+branch)
+	if g.HasBranch(name, branch) {
+		runGit(g.t, work, "fetch", "--quiet", g.Dir(name), branch)
+		runGit(g.t, work, "reset", "--quiet", "--hard", "FETCH_HEAD")
+	}
+	writeFiles(g.t, work, files)
+	runGit(g.t, work, "add", "--all")
+	runGit(g.t, work, "commit", "--quiet", "--message", "Seed "+branch)
+	// Fetch into the bare repository, so that its post-receive hook does not run.
+	runGit(g.t, g.Dir(name), "fetch", "--quiet", work, "+HEAD:refs/heads/"+branch)
+	return runGit(g.t, work, "rev-parse", "HEAD")
+}
+
+// CreateBranch creates a branch in a repository, pointing at another branch.
+// It can be called from a server goroutine, so it returns an error instead of
+// stopping the test.
+func (g *gitServer) CreateBranch(name, branch, from string) error {
+	if out, err := gitCommand(g.Dir(name), "branch", branch, from).CombinedOutput(); err != nil {
+		return fmt.Errorf("git branch %s %s: %w: %s", branch, from, err, out)
+	}
+	return nil
+}
+
+// Branches returns a map of branch names to commit hashes in a repository.
+func (g *gitServer) Branches(name string) map[string]string {
+	branches := make(map[string]string)
+	out := runGit(g.t, g.Dir(name), "for-each-ref", "--format=%(refname:short) %(objectname)", "refs/heads/")
+	for _, line := range strings.Split(out, "\n") {
+		if branch, rev, ok := strings.Cut(line, " "); ok {
+			branches[branch] = rev
+		}
+	}
+	return branches
+}
+
+// HasBranch reports whether a branch exists in a repository. It can be called
+// from a server goroutine.
+func (g *gitServer) HasBranch(name, branch string) bool {
+	return gitCommand(g.Dir(name), "rev-parse", "--verify", "--quiet", "refs/heads/"+branch).Run() == nil
+}
+
+// Pushes returns the pushes received so far.
+func (g *gitServer) Pushes() []gitPush {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]gitPush(nil), g.pushes...)
+}
+
+// readPushLog reads and removes the log written by the post-receive hook. It
+// runs in the server's goroutine, so it reports errors without stopping the test.
+func (g *gitServer) readPushLog(repo string) (gitPush, bool) {
+	logFile := filepath.Join(g.Dir(strings.TrimSuffix(repo, ".git")), "pushes.log")
+	f, err := os.Open(logFile)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			g.t.Error(err)
+		}
+		return gitPush{}, false
+	}
+	defer os.Remove(logFile)
+	defer f.Close()
+
+	push := gitPush{Repo: strings.TrimSuffix(repo, ".git"), Options: make(map[string]string)}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		kind, rest, _ := strings.Cut(scanner.Text(), " ")
+		switch kind {
+		case "update":
+			fields := strings.Fields(rest)
+			if len(fields) == 3 {
+				push.Updates = append(push.Updates, gitRefUpdate{OldRev: fields[0], NewRev: fields[1], Ref: fields[2]})
+			}
+		case "option":
+			k, v, _ := strings.Cut(rest, "=")
+			push.Options[k] = v
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		g.t.Error(err)
+	}
+	return push, true
+}
+
+// gitEnv returns environment variables which make Git commands repeatable,
+// regardless of the user's own Git configuration.
+func gitEnv() []string {
+	return []string{
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_CONFIG_GLOBAL=" + os.DevNull,
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_AUTHOR_NAME=Mock User",
+		"GIT_AUTHOR_EMAIL=mock-user@example.com",
+		"GIT_AUTHOR_DATE=2014-04-01T10:00:00Z",
+		"GIT_COMMITTER_NAME=Mock User",
+		"GIT_COMMITTER_EMAIL=mock-user@example.com",
+		"GIT_COMMITTER_DATE=2014-04-01T10:00:00Z",
+	}
+}
+
+// gitCommand builds a Git command to run in a directory.
+func gitCommand(dir string, args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), gitEnv()...)
+	return cmd
+}
+
+// runGit runs a Git command in a directory, requires it to succeed, and
+// returns its trimmed output.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := gitCommand(dir, args...).CombinedOutput()
+	require.NoError(t, err, "git %s: %s", strings.Join(args, " "), out)
+	return strings.TrimSpace(string(out))
+}
+
+// writeFiles writes files (keyed by relative path) in a directory.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+}
@@ -3,6 +3,7 @@ module github.com/platformsh/legacy-cli/tests
 go 1.25
 
 require (
+	github.com/creack/pty v1.1.24
 	github.com/go-chi/chi/v5 v5.2.3
 	github.com/platformsh/cli v0.0.0-20260128142111-698419ab4b95
 	github.com/stretchr/testify v1.11.1
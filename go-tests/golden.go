@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"errors"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Golden files hold the expected output of commands, under testdata/<TestName>/.
+//
+// Run the tests with the -update flag to regenerate them, and review the diff:
+//
+//	go test ./... -run TestActivityList -update
+var updateGolden = flag.Bool("update", false, "update golden files under testdata/ instead of comparing against them")
+
+const goldenDir = "testdata"
+
+// Mask registers a volatile value, such as a generated project ID, which will
+// be replaced with a stable placeholder before output is compared to golden files.
+func (f *cmdFactory) Mask(value, placeholder string) {
+	f.masks = append(f.masks, value, placeholder)
+}
+
+// RunGolden runs a command, asserts that it did not error, and compares its
+// normal (stdout) output to the golden file "<name>.stdout".
+func (f *cmdFactory) RunGolden(name string, args ...string) {
+	f.t.Helper()
+	assertGolden(f.t, name+".stdout", f.applyMasks(f.Run(args...)))
+}
+
+// RunCombinedGolden runs a command, compares its stdout and stderr to the
+// golden files "<name>.stdout" and "<name>.stderr", and returns the error.
+func (f *cmdFactory) RunCombinedGolden(name string, args ...string) error {
+	f.t.Helper()
+	stdOut, stdErr, err := f.RunCombinedOutput(args...)
+	assertGolden(f.t, name+".stdout", f.applyMasks(stdOut))
+	assertGolden(f.t, name+".stderr", f.applyMasks(stdErr))
+	return err
+}
+
+func (f *cmdFactory) applyMasks(s string) string {
+	if len(f.masks) == 0 {
+		return s
+	}
+	return strings.NewReplacer(f.masks...).Replace(s)
+}
+
+// assertGolden compares actual to the contents of a golden file, or writes the
+// file if the -update flag is set. Testify prints a diff on mismatch.
+func assertGolden(t *testing.T, name, actual string) {
+	t.Helper()
+	path := goldenPath(t, name)
+	if *updateGolden {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte(actual), 0o600))
+		t.Logf("Updated golden file: %s", path)
+		return
+	}
+	expected, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Golden file not found: %s (run the test with -update to create it)", path)
+	}
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), actual, "output does not match golden file %s (run the test with -update to regenerate it)", path)
+}
+
+func goldenPath(t *testing.T, name string) string {
+	return filepath.Join(goldenDir, filepath.FromSlash(t.Name()), name)
+}
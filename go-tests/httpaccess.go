@@ -0,0 +1,212 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// httpAccess is an environment's HTTP access control settings.
+type httpAccess struct {
+	Addresses []httpAccessAddress `json:"addresses"`
+	// BasicAuth maps usernames to passwords.
+	BasicAuth map[string]string `json:"basic_auth"`
+	IsEnabled bool              `json:"is_enabled"`
+}
+
+// httpAccessAddress is an IP address rule, e.g. allowing "192.0.2.0/24".
+type httpAccessAddress struct {
+	Permission string `json:"permission"`
+	Address    string `json:"address"`
+}
+
+// httpAccessAPI stands in for the HTTP access settings of environments,
+// which the mock API lacks.
+type httpAccessAPI struct {
+	s *scenario
+
+	mu       sync.Mutex
+	settings map[string]*httpAccess
+}
+
+// WithHTTPAccess sets the HTTP access settings of an environment, and serves
+// them from an httpAccessAPI, which adds them to environments from the mock
+// API, and saves changes to them.
+//
+// Environments without their own settings inherit them from their parent, or
+// have access control enabled with no rules. Changing an inherited setting
+// gives the environment its own settings.
+func (s *scenario) WithHTTPAccess(envName string, access *httpAccess) *scenario {
+	if s.httpAccess == nil {
+		s.httpAccess = &httpAccessAPI{s: s, settings: make(map[string]*httpAccess)}
+		s.Use(s.httpAccess.middleware)
+	}
+	s.httpAccess.mu.Lock()
+	defer s.httpAccess.mu.Unlock()
+	s.httpAccess.settings[envName] = access.normalize()
+	return s
+}
+
+// HTTPAccess returns the HTTP access settings that apply to an environment.
+func (s *scenario) HTTPAccess(envName string) *httpAccess {
+	s.httpAccess.mu.Lock()
+	defer s.httpAccess.mu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.httpAccess.effective(envName)
+}
+
+func (api *httpAccessAPI) middleware(next http.Handler) http.Handler {
+	return standIn(func(r chi.Router) {
+		r.Get("/projects/{project}/environments", func(w http.ResponseWriter, req *http.Request) {
+			api.handleGetEnv(w, req, next)
+		})
+		r.Get("/projects/{project}/environments/{environment}", func(w http.ResponseWriter, req *http.Request) {
+			api.handleGetEnv(w, req, next)
+		})
+		r.Patch("/projects/{project}/environments/{environment}", func(w http.ResponseWriter, req *http.Request) {
+			api.handleUpdate(w, req, next)
+		})
+	})(next)
+}
+
+// effective returns an environment's own HTTP access settings, or those
+// inherited from its nearest ancestor with settings. Both api.mu and the
+// scenario's lock must be held.
+func (api *httpAccessAPI) effective(envName string) *httpAccess {
+	for name := envName; name != ""; {
+		if access, ok := api.settings[name]; ok {
+			return access
+		}
+		env := api.s.findEnv(name)
+		if env == nil {
+			break
+		}
+		name, _ = env.Parent.(string)
+	}
+	return (&httpAccess{IsEnabled: true}).normalize()
+}
+
+// normalize makes empty settings serialize as an empty list and map, as in
+// the API.
+func (a *httpAccess) normalize() *httpAccess {
+	if a.Addresses == nil {
+		a.Addresses = []httpAccessAddress{}
+	}
+	if a.BasicAuth == nil {
+		a.BasicAuth = map[string]string{}
+	}
+	return a
+}
+
+// handleGetEnv adds HTTP access settings to an environment, or a
+// list of environments, from the mock API.
+func (api *httpAccessAPI) handleGetEnv(w http.ResponseWriter, req *http.Request, next http.Handler) {
+	rec := httptest.NewRecorder()
+	next.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		copyResponse(w, rec)
+		return
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.s.mu.Lock()
+	defer api.s.mu.Unlock()
+	if chi.URLParam(req, "environment") == "" {
+		var list []map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+			copyResponse(w, rec)
+			return
+		}
+		for _, env := range list {
+			name, _ := env["name"].(string)
+			env["http_access"] = api.effective(name)
+		}
+		writeJSON(w, http.StatusOK, list)
+		return
+	}
+	env := map[string]any{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &env); err != nil {
+		copyResponse(w, rec)
+		return
+	}
+	env["http_access"] = api.effective(chi.URLParam(req, "environment"))
+	writeJSON(w, http.StatusOK, env)
+}
+
+// handleUpdate saves changes to an environment's HTTP access
+// settings. Each field replaces the current one, and null clears it. Other
+// updates are passed on to the mock API.
+func (api *httpAccessAPI) handleUpdate(w http.ResponseWriter, req *http.Request, next http.Handler) {
+	body, _ := io.ReadAll(req.Body)
+	var params struct {
+		HTTPAccess *struct {
+			Addresses json.RawMessage `json:"addresses"`
+			BasicAuth json.RawMessage `json:"basic_auth"`
+			IsEnabled *bool           `json:"is_enabled"`
+		} `json:"http_access"`
+	}
+	if json.Unmarshal(body, &params) != nil || params.HTTPAccess == nil {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, req)
+		return
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.s.mu.Lock()
+	defer api.s.mu.Unlock()
+	env := api.s.findEnv(chi.URLParam(req, "environment"))
+	if env == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Environment not found"})
+		return
+	}
+	current := api.effective(env.Name)
+	updated := &httpAccess{
+		Addresses: current.Addresses,
+		BasicAuth: current.BasicAuth,
+		IsEnabled: current.IsEnabled,
+	}
+	if params.HTTPAccess.Addresses != nil {
+		updated.Addresses = nil
+		if err := json.Unmarshal(params.HTTPAccess.Addresses, &updated.Addresses); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"message": "Invalid addresses"})
+			return
+		}
+	}
+	if params.HTTPAccess.BasicAuth != nil {
+		updated.BasicAuth = nil
+		if err := json.Unmarshal(params.HTTPAccess.BasicAuth, &updated.BasicAuth); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"message": "Invalid basic_auth"})
+			return
+		}
+	}
+	if params.HTTPAccess.IsEnabled != nil {
+		updated.IsEnabled = *params.HTTPAccess.IsEnabled
+	}
+	for _, a := range updated.Addresses {
+		if a.Permission != "allow" && a.Permission != "deny" {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"message": "Invalid permission: " + a.Permission})
+			return
+		}
+		if _, _, err := net.ParseCIDR(a.Address); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"message": "Invalid address: " + a.Address})
+			return
+		}
+	}
+	api.settings[env.Name] = updated.normalize()
+
+	data := map[string]any{}
+	b, _ := json.Marshal(env)
+	_ = json.Unmarshal(b, &data)
+	data["http_access"] = api.settings[env.Name]
+	writeJSON(w, http.StatusOK, map[string]any{
+		"_embedded": map[string]any{"entity": data, "activities": []any{}},
+	})
+}
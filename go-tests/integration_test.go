@@ -0,0 +1,345 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupIntegrationTest creates a scenario with GitHub, Bitbucket and webhook
+// integrations.
+func setupIntegrationTest(t *testing.T) *scenario {
+	return newScenario(t).
+		WithEnv("main", "production", "active", nil).
+		WithIntegrations(
+			&integrationFixture{ID: "int1", Type: "github", Values: map[string]any{
+				"repository":          "owner/repo",
+				"token":               "ghp-secret-token",
+				"build_pull_requests": true,
+				"fetch_branches":      true,
+				"prune_branches":      true,
+			}},
+			&integrationFixture{ID: "int2", Type: "bitbucket", Values: map[string]any{
+				"repository":      "owner/bb-repo",
+				"app_credentials": map[string]any{"key": "bb-key", "secret": "bb-secret"},
+			}},
+			&integrationFixture{ID: "int3", Type: "webhook", Values: map[string]any{
+				"url":          "https://hooks.example.com/platform",
+				"events":       []any{"*"},
+				"states":       []any{"complete"},
+				"environments": []any{"*"},
+			}},
+		)
+}
+
+func TestIntegrationAdd(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil).
+		WithIntegrations()
+	f, p := s.Factory(), s.ProjectID
+
+	scriptPath := filepath.Join(t.TempDir(), "notify.js")
+	require.NoError(t, os.WriteFile(scriptPath, []byte("console.log(activity.type);\n"), 0o600))
+
+	// Adding a Bitbucket integration checks its credentials with Bitbucket
+	// itself, so it is not covered here.
+	cases := []struct {
+		name     string
+		args     []string
+		expected map[string]any
+	}{
+		{
+			"github",
+			[]string{"--type", "github", "--repository", "owner/repo", "--token", "ghp-secret-token",
+				"--build-pull-requests", "false", "--fetch-branches", "false"},
+			map[string]any{
+				"type":                       "github",
+				"repository":                 "owner/repo",
+				"token":                      "ghp-secret-token",
+				"build_pull_requests":        false,
+				"fetch_branches":             false,
+				"prune_branches":             false,
+				"environment_init_resources": "parent",
+			},
+		},
+		{
+			"gitlab",
+			[]string{"--type", "gitlab", "--server-project", "namespace/repo", "--token", "glpat-secret-token",
+				"--base-url", "https://gitlab.example.com", "--build-merge-requests", "false", "--resources-init", "minimum"},
+			map[string]any{
+				"type":                       "gitlab",
+				"project":                    "namespace/repo",
+				"token":                      "glpat-secret-token",
+				"base_url":                   "https://gitlab.example.com",
+				"build_merge_requests":       false,
+				"fetch_branches":             true,
+				"prune_branches":             true,
+				"environment_init_resources": "minimum",
+			},
+		},
+		{
+			"webhook",
+			[]string{"--type", "webhook", "--url", "https://hooks.example.com/platform", "--shared-key", "jws-secret",
+				"--events", "environment.push,environment.redeploy"},
+			map[string]any{
+				"type":         "webhook",
+				"url":          "https://hooks.example.com/platform",
+				"shared_key":   "jws-secret",
+				"events":       []any{"environment.push", "environment.redeploy"},
+				"states":       []any{"complete"},
+				"environments": []any{"*"},
+			},
+		},
+		{
+			"health.email",
+			[]string{"--type", "health.email", "--from-address", "alerts@example.com", "--recipients", "ops@example.com,#admins"},
+			map[string]any{
+				"type":         "health.email",
+				"from_address": "alerts@example.com",
+				"recipients":   []any{"ops@example.com", "#admins"},
+			},
+		},
+		{
+			"script",
+			[]string{"--type", "script", "--file", scriptPath, "--events", "environment.push"},
+			map[string]any{
+				"type":         "script",
+				"script":       "console.log(activity.type);\n",
+				"events":       []any{"environment.push"},
+				"states":       []any{"complete"},
+				"environments": []any{"*"},
+			},
+		},
+	}
+	for n, c := range cases {
+		s.Recorder.Reset()
+		_, stdErr, err := f.RunCombinedOutput(append([]string{"integration:add", "-p", p}, c.args...)...)
+		assert.NoError(t, err, c.name)
+		assert.Contains(t, stdErr, "Created integration int"+strconv.Itoa(n+1)+" (type: "+c.name+")")
+		body := s.Recorder.RequireOne(t, "POST", "/projects/"+p+"/integrations").JSON(t)
+		assert.Equal(t, c.expected, body, c.name)
+	}
+
+	assertTrimmed(t, "int1,github\nint2,gitlab\nint3,webhook\nint4,health.email\nint5,script",
+		f.Run("integrations", "-p", p, "--format", "csv", "--no-header", "--columns", "id,type"))
+}
+
+func TestIntegrationAddInvalid(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil).
+		WithIntegrations().
+		WithIntegrationTypes("github", "webhook", "health.email").
+		WithIntegrationValidator(func(i *integrationFixture) map[string]string {
+			if i.Values["repository"] == "owner/missing" {
+				return map[string]string{"repository": "Repository not found: owner/missing"}
+			}
+			return nil
+		})
+	f, p := s.Factory(), s.ProjectID
+
+	_, stdErr, err := f.RunCombinedOutput("integration:add", "-p", p, "--type", "script", "--file", "notify.js")
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "The integration type 'script' is not available on this project.")
+
+	_, stdErr, err = f.RunCombinedOutput("integration:add", "-p", p, "--type", "webhook", "--url", "https://hooks.example.com", "--token", "abc")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The option --token cannot be used with the integration type webhook.")
+
+	_, stdErr, err = f.RunCombinedOutput("integration:add", "-p", p, "--type", "health.email", "--recipients", "ops@example.com,not-an-email")
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "Invalid email address(es): not-an-email")
+	s.Recorder.AssertNone(t, "POST", "/integrations")
+
+	stdOut, stdErr, err := f.RunCombinedOutput("integration:add", "-p", p, "--type", "github", "--repository", "owner/missing", "--token", "abc")
+	assertExitCode(t, 4, err)
+	assert.Contains(t, stdErr, "The integration is invalid.")
+	assert.Contains(t, stdErr, "The following error was found:")
+	assertTrimmed(t, "repository: Repository not found: owner/missing", stdOut)
+
+	_, stdErr, err = f.RunCombinedOutput("integrations", "-p", p)
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "No integrations found")
+}
+
+func TestIntegrationGet(t *testing.T) {
+	t.Parallel()
+	s := setupIntegrationTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	// Secrets are hidden.
+	stdOut := f.Run("integration:get", "-p", p, "int1")
+	assert.Contains(t, stdOut, "owner/repo")
+	assert.Contains(t, stdOut, "******")
+	assert.NotContains(t, stdOut, "ghp-secret-token")
+	assertTrimmed(t, "******", f.Run("integration:get", "-p", p, "int1", "-P", "token"))
+
+	stdOut = f.Run("integration:get", "-p", p, "int2", "-P", "app_credentials")
+	assert.Contains(t, stdOut, "bb-key")
+	assert.Contains(t, stdOut, "******")
+	assert.NotContains(t, stdOut, "bb-secret")
+	assert.NotContains(t, f.Run("integration:get", "-p", p, "int2"), "bb-secret")
+
+	assertTrimmed(t, s.APIServer.URL+"/projects/"+p+"/integrations/int1/hook",
+		f.Run("integration:get", "-p", p, "int1", "-P", "hook_url"))
+	assertTrimmed(t, "- '*'", f.Run("integration:get", "-p", p, "int3", "-P", "events"))
+
+	_, stdErr, err := f.RunCombinedOutput("integration:get", "-p", p, "int")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, `The partial ID "int" is ambiguous`)
+
+	_, stdErr, err = f.RunCombinedOutput("integration:get", "-p", p, "missing")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, `Integration not found: "missing"`)
+}
+
+func TestIntegrationList(t *testing.T) {
+	t.Parallel()
+	s := setupIntegrationTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	stdOut, stdErr, err := f.RunCombinedOutput("integrations", "-p", p)
+	assert.NoError(t, err)
+	assert.Contains(t, stdOut, "Repository: owner/repo")
+	assert.Contains(t, stdOut, "Hook URL: "+s.APIServer.URL+"/projects/"+p+"/integrations/int1/hook")
+	assert.Contains(t, stdOut, "URL: https://hooks.example.com/platform")
+	assert.NotContains(t, stdOut, "secret")
+	assert.Contains(t, stdErr, "View integration details with:")
+
+	assertTrimmed(t, "int3,webhook", f.Run("integrations", "-p", p, "--type", "webhook", "--format", "csv", "--no-header", "--columns", "id,type"))
+}
+
+func TestIntegrationUpdate(t *testing.T) {
+	t.Parallel()
+	s := setupIntegrationTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	_, stdErr, err := f.RunCombinedOutput("integration:update", "-p", p, "int1", "--repository", "owner/repo")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "No changed values were provided to update.")
+	s.Recorder.AssertNone(t, "PATCH", "/integrations/int1")
+
+	_, stdErr, err = f.RunCombinedOutput("integration:update", "-p", p, "int1", "--url", "https://example.com")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The option --url cannot be used with the integration type github.")
+
+	// Disabling fetch_branches also disables prune_branches.
+	_, stdErr, err = f.RunCombinedOutput("integration:update", "-p", p, "int1", "--fetch-branches", "false")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "Integration int1 (github) updated")
+	s.Recorder.AssertOneJSON(t, "PATCH", "/integrations/int1", `{"fetch_branches": false, "prune_branches": false}`)
+	assertTrimmed(t, "false", f.Run("integration:get", "-p", p, "int1", "-P", "prune_branches"))
+
+	s.WithIntegrationValidator(func(i *integrationFixture) map[string]string {
+		if i.Values["url"] == "http://localhost" {
+			return map[string]string{"url": "The URL must be publicly accessible."}
+		}
+		return nil
+	})
+	stdOut, stdErr, err := f.RunCombinedOutput("integration:update", "-p", p, "int3", "--url", "http://localhost")
+	assertExitCode(t, 4, err)
+	assert.Contains(t, stdErr, "The integration int3 (type: webhook) is invalid.")
+	assertTrimmed(t, "url: The URL must be publicly accessible.", stdOut)
+	assertTrimmed(t, "https://hooks.example.com/platform", f.Run("integration:get", "-p", p, "int3", "-P", "url"))
+}
+
+func TestIntegrationDelete(t *testing.T) {
+	t.Parallel()
+	s := setupIntegrationTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	session := f.RunInteractive("integration:delete", "-p", p, "int3")
+	session.Expect("Delete the integration int3 (type: webhook)? [Y/n]")
+	session.SendLine("n")
+	assert.Error(t, session.Wait())
+	s.Recorder.AssertNone(t, "DELETE", "/integrations/int3")
+
+	_, stdErr, err := f.RunCombinedOutput("integration:delete", "-p", p, "int3")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "Deleted integration int3")
+	s.Recorder.AssertCount(t, 1, "DELETE", "/projects/"+p+"/integrations/int3")
+
+	assertTrimmed(t, "int1\nint2", f.Run("integrations", "-p", p, "--format", "csv", "--no-header", "--columns", "id"))
+}
+
+func TestIntegrationValidate(t *testing.T) {
+	t.Parallel()
+	s := setupIntegrationTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	_, stdErr, err := f.RunCombinedOutput("integration:validate", "-p", p, "int1")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "Validating the integration int1 (type: github)...")
+	assert.Contains(t, stdErr, "The integration is valid.")
+
+	// The token has since been revoked and the repository deleted.
+	s.WithIntegrationValidator(func(i *integrationFixture) map[string]string {
+		if i.Type == "github" {
+			return map[string]string{
+				"repository": "Repository not found: owner/repo",
+				"token":      "The token is invalid or has been revoked.",
+			}
+		}
+		return nil
+	})
+	stdOut, stdErr, err := f.RunCombinedOutput("integration:validate", "-p", p, "int1")
+	assertExitCode(t, 4, err)
+	assert.Contains(t, stdErr, "The following 2 errors were found:")
+	assertTrimmed(t, "repository: Repository not found: owner/repo\ntoken: The token is invalid or has been revoked.", stdOut)
+
+	_, stdErr, err = f.RunCombinedOutput("integration:validate", "-p", p, "int3")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "The integration is valid.")
+}
+
+func TestIntegrationActivities(t *testing.T) {
+	t.Parallel()
+	s := setupIntegrationTest(t).WithActivitySimulator()
+	f, p := s.Factory(), s.ProjectID
+
+	fetch := s.Sim.Start(activitySpec{
+		Type:        "integration.github.fetch",
+		Description: "Fetching from <integration>owner/repo</integration>",
+		Integration: "int1",
+		Steps:       activitySucceeds("Fetching branches from GitHub"),
+	})
+	webhook := s.Sim.Start(activitySpec{
+		Type:        "integration.webhook",
+		Description: "Sending activity to <integration>https://hooks.example.com/platform</integration>",
+		Integration: "int3",
+	})
+	hooks := s.Sim.Start(activitySpec{
+		Type:        "integration.github.register_hooks",
+		Description: "Registering hooks on <integration>owner/repo</integration>",
+		Integration: "int1",
+		Steps:       activitySucceeds("Registered webhook"),
+	})
+
+	assertTrimmed(t, hooks+",integration.github.register_hooks\n"+fetch+",integration.github.fetch",
+		f.Run("integration:activities", "-p", p, "int1", "--format", "csv", "--no-header", "--columns", "id,type"))
+	assertTrimmed(t, webhook+",integration.webhook",
+		f.Run("integration:activities", "-p", p, "int3", "--format", "csv", "--no-header", "--columns", "id,type"))
+
+	_, stdErr, err := f.RunCombinedOutput("integration:activities", "-p", p, "int2")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "No activities found")
+
+	// The most recent activity is used by default.
+	assertTrimmed(t, "integration.github.register_hooks", f.Run("integration:activity:get", "-p", p, "int1", "-P", "type"))
+	assertTrimmed(t, "int1", f.Run("integration:activity:get", "-p", p, "int1", fetch, "-P", "integration"))
+
+	stdOut, stdErr, err := f.RunCombinedOutput("integration:activity:log", "-p", p, "int1", fetch)
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "Integration ID: int1")
+	assert.Contains(t, stdErr, "Activity ID: "+fetch)
+	assert.Equal(t, "Fetching branches from GitHub\n", stdOut)
+
+	_, stdErr, err = f.RunCombinedOutput("integration:activity:log", "-p", p, "int2")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "No integration activities found")
+}
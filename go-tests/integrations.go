@@ -0,0 +1,316 @@
+package tests
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/platformsh/cli/pkg/mockapi"
+)
+
+// integrationFixture is a project integration, served by the integrations
+// stand-in.
+type integrationFixture struct {
+	// ID defaults to "int" followed by a number.
+	ID   string
+	Type string
+	// Values are the integration's other properties, e.g. "repository" and
+	// "token" for a GitHub integration.
+	Values    map[string]any
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// integrationRequiredFields lists the integration types which the stand-in
+// supports, and the properties they require.
+var integrationRequiredFields = map[string][]string{
+	"github":       {"repository", "token"},
+	"gitlab":       {"project", "token"},
+	"bitbucket":    {"repository", "app_credentials"},
+	"webhook":      {"url"},
+	"health.email": {"recipients"},
+	"script":       {"script"},
+}
+
+// gitSourceIntegrationTypes are the integration types which receive hooks
+// from an external Git repository.
+var gitSourceIntegrationTypes = []string{"github", "gitlab", "bitbucket"}
+
+// integrationsAPI stands in for the project's integrations API.
+type integrationsAPI struct {
+	s *scenario
+
+	mu           sync.Mutex
+	integrations []*integrationFixture
+	validator    func(i *integrationFixture) map[string]string
+}
+
+// WithIntegrations serves the project's integrations from an integrationsAPI,
+// and adds integrations. Integrations are validated on creation, on update and
+// through the "#validate" operation: see WithIntegrationValidator, and
+// WithWebhookReceiver for webhook URLs.
+//
+// Integration activities are served by the activity simulator, from
+// activities started with an Integration ID.
+func (s *scenario) WithIntegrations(integrations ...*integrationFixture) *scenario {
+	if s.integrations == nil {
+		s.integrations = &integrationsAPI{s: s}
+		s.Project.Links["integrations"] = mockapi.HALLink{HREF: "/projects/" + url.PathEscape(s.ProjectID) + "/integrations"}
+		s.serveCapabilities()
+		s.Use(standIn(s.integrations.routes))
+	}
+	s.integrations.mu.Lock()
+	defer s.integrations.mu.Unlock()
+	for _, i := range integrations {
+		s.integrations.add(i)
+	}
+	return s
+}
+
+// WithIntegrationTypes makes only the given integration types available on
+// the project, through its capabilities.
+func (s *scenario) WithIntegrationTypes(types ...string) *scenario {
+	s.serveCapabilities().setIntegrationTypes(types)
+	return s
+}
+
+// WithIntegrationValidator serves integrations (see WithIntegrations), and
+// sets a function which checks them after their required properties, as the
+// API checks external resources (e.g. that a repository exists). It returns
+// errors keyed by property name.
+func (s *scenario) WithIntegrationValidator(validate func(i *integrationFixture) map[string]string) *scenario {
+	s.WithIntegrations()
+	s.integrations.mu.Lock()
+	defer s.integrations.mu.Unlock()
+	s.integrations.validator = validate
+	return s
+}
+
+func (api *integrationsAPI) routes(r chi.Router) {
+	r.Get("/projects/{project}/integrations", api.handleList)
+	r.Post("/projects/{project}/integrations", api.handleCreate)
+	r.Get("/projects/{project}/integrations/{id}", api.handleGet)
+	r.Patch("/projects/{project}/integrations/{id}", api.handleUpdate)
+	r.Delete("/projects/{project}/integrations/{id}", api.handleDelete)
+	r.Post("/projects/{project}/integrations/{id}/validate", api.handleValidate)
+}
+
+func (api *integrationsAPI) add(i *integrationFixture) {
+	if i.ID == "" {
+		for n := len(api.integrations) + 1; i.ID == "" || api.find(i.ID) != nil; n++ {
+			i.ID = "int" + strconv.Itoa(n)
+		}
+	}
+	if i.Values == nil {
+		i.Values = map[string]any{}
+	}
+	if i.CreatedAt.IsZero() {
+		i.CreatedAt, _ = time.Parse(time.RFC3339, "2014-04-01T10:00:00Z")
+	}
+	if i.UpdatedAt.IsZero() {
+		i.UpdatedAt = i.CreatedAt
+	}
+	api.integrations = append(api.integrations, i)
+}
+
+func (api *integrationsAPI) find(id string) *integrationFixture {
+	for _, i := range api.integrations {
+		if i.ID == id {
+			return i
+		}
+	}
+	return nil
+}
+
+// webhookURLError checks the URL of a webhook integration, if webhooks are
+// delivered to a receiver (see checkWebhookURL). The check makes a request, so
+// api.mu must not be held.
+func (api *integrationsAPI) webhookURLError(i *integrationFixture) string {
+	if i.Type != "webhook" || api.s.Webhooks == nil {
+		return ""
+	}
+	u, _ := i.Values["url"].(string)
+	if u == "" {
+		return ""
+	}
+	return checkWebhookURL(u)
+}
+
+// integrationErrors returns the validation errors of an integration, if any,
+// given the result of webhookURLError.
+func (api *integrationsAPI) integrationErrors(i *integrationFixture, urlError string) map[string]string {
+	required, ok := integrationRequiredFields[i.Type]
+	if !ok {
+		return map[string]string{"type": "Unsupported integration type: " + i.Type}
+	}
+	errs := map[string]string{}
+	for _, name := range required {
+		if v, ok := i.Values[name]; !ok || v == nil || v == "" {
+			errs[name] = "This field is required."
+		}
+	}
+	if len(errs) == 0 && urlError != "" {
+		errs["url"] = urlError
+	}
+	if len(errs) == 0 && api.validator != nil {
+		errs = api.validator(i)
+	}
+	return errs
+}
+
+func writeIntegrationErrors(w http.ResponseWriter, errs map[string]string) {
+	writeJSON(w, http.StatusBadRequest, map[string]any{
+		"code":    http.StatusBadRequest,
+		"message": "Bad Request",
+		"detail":  errs,
+	})
+}
+
+func (api *integrationsAPI) handleList(w http.ResponseWriter, _ *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	list := []any{}
+	for _, i := range api.integrations {
+		list = append(list, api.integrationData(i))
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (api *integrationsAPI) handleGet(w http.ResponseWriter, req *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	i := api.find(chi.URLParam(req, "id"))
+	if i == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Integration not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, api.integrationData(i))
+}
+
+func (api *integrationsAPI) handleCreate(w http.ResponseWriter, req *http.Request) {
+	var values map[string]any
+	if err := json.NewDecoder(req.Body).Decode(&values); err != nil {
+		writeIntegrationErrors(w, map[string]string{"": "Invalid integration parameters"})
+		return
+	}
+	i := &integrationFixture{
+		Values:    values,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		UpdatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	i.Type, _ = values["type"].(string)
+	delete(values, "type")
+	urlError := api.webhookURLError(i)
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if errs := api.integrationErrors(i, urlError); len(errs) > 0 {
+		writeIntegrationErrors(w, errs)
+		return
+	}
+	api.add(i)
+	writeJSON(w, http.StatusCreated, map[string]any{"_embedded": map[string]any{"entity": api.integrationData(i)}})
+}
+
+func (api *integrationsAPI) handleUpdate(w http.ResponseWriter, req *http.Request) {
+	var values map[string]any
+	if err := json.NewDecoder(req.Body).Decode(&values); err != nil {
+		writeIntegrationErrors(w, map[string]string{"": "Invalid integration parameters"})
+		return
+	}
+	api.mu.Lock()
+	i := api.find(chi.URLParam(req, "id"))
+	if i == nil {
+		api.mu.Unlock()
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Integration not found"})
+		return
+	}
+	if t, ok := values["type"]; ok && t != i.Type {
+		api.mu.Unlock()
+		writeIntegrationErrors(w, map[string]string{"type": "The integration type cannot be changed."})
+		return
+	}
+	delete(values, "type")
+	updated := *i
+	updated.Values = maps.Clone(i.Values)
+	maps.Copy(updated.Values, values)
+	api.mu.Unlock()
+
+	urlError := api.webhookURLError(&updated)
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if errs := api.integrationErrors(&updated, urlError); len(errs) > 0 {
+		writeIntegrationErrors(w, errs)
+		return
+	}
+	i.Values = updated.Values
+	i.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	writeJSON(w, http.StatusOK, map[string]any{"_embedded": map[string]any{"entity": api.integrationData(i)}})
+}
+
+func (api *integrationsAPI) handleDelete(w http.ResponseWriter, req *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	i := api.find(chi.URLParam(req, "id"))
+	if i == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Integration not found"})
+		return
+	}
+	api.integrations = slices.DeleteFunc(api.integrations, func(other *integrationFixture) bool {
+		return other == i
+	})
+	writeJSON(w, http.StatusOK, map[string]any{"_embedded": map[string]any{"activities": []any{}}})
+}
+
+func (api *integrationsAPI) handleValidate(w http.ResponseWriter, req *http.Request) {
+	api.mu.Lock()
+	i := api.find(chi.URLParam(req, "id"))
+	if i == nil {
+		api.mu.Unlock()
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Integration not found"})
+		return
+	}
+	snapshot := *i
+	snapshot.Values = maps.Clone(i.Values)
+	api.mu.Unlock()
+
+	urlError := api.webhookURLError(&snapshot)
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if errs := api.integrationErrors(&snapshot, urlError); len(errs) > 0 {
+		writeIntegrationErrors(w, errs)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{})
+}
+
+// integrationData returns the API representation of an integration. Secrets
+// are returned as they were sent, for the CLI to hide.
+func (api *integrationsAPI) integrationData(i *integrationFixture) map[string]any {
+	self := "/projects/" + url.PathEscape(api.s.ProjectID) + "/integrations/" + url.PathEscape(i.ID)
+	links := map[string]any{
+		"self":      map[string]any{"href": self},
+		"#edit":     map[string]any{"href": self},
+		"#delete":   map[string]any{"href": self},
+		"#validate": map[string]any{"href": self + "/validate"},
+	}
+	if slices.Contains(gitSourceIntegrationTypes, i.Type) {
+		links["#hook"] = map[string]any{"href": self + "/hook"}
+	}
+	data := maps.Clone(i.Values)
+	data["id"] = i.ID
+	data["type"] = i.Type
+	data["created_at"] = i.CreatedAt.Format(time.RFC3339)
+	data["updated_at"] = i.UpdatedAt.Format(time.RFC3339)
+	data["_links"] = links
+	return data
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/platformsh/cli/pkg/mockapi"
+)
+
+// envOperations are the environment lifecycle operations, with the statuses
+// from which they are available and the status they lead to. The "delete"
+// operation removes an inactive environment.
+var envOperations = map[string]struct {
+	from []string
+	to   string
+}{
+	"activate":   {from: []string{"inactive"}, to: "active"},
+	"pause":      {from: []string{"active"}, to: "paused"},
+	"resume":     {from: []string{"paused"}, to: "active"},
+	"deactivate": {from: []string{"active", "paused"}, to: "inactive"},
+	"delete":     {from: []string{"inactive"}},
+}
+
+// envLifecycleAPI stands in for environment status changes. It changes the
+// scenario's environments, so it has no state of its own.
+type envLifecycleAPI struct {
+	s *scenario
+}
+
+// WithEnvLifecycle serves environment status changes (activating, pausing,
+// resuming, deactivating and deleting) from an envLifecycleAPI, which changes
+// the environments' status straight away.
+//
+// Environments have links for the operations available in their current
+// status. The default branch cannot be paused, deactivated or deleted.
+// Operations return no activities: tests can start them with the activity
+// simulator (see WithActivitySimulator), which must be added first.
+func (s *scenario) WithEnvLifecycle() *scenario {
+	if s.lifecycle != nil {
+		return s
+	}
+	s.lifecycle = &envLifecycleAPI{s: s}
+	return s.Use(standIn(s.lifecycle.routes))
+}
+
+func (api *envLifecycleAPI) routes(r chi.Router) {
+	r.Post("/projects/{project}/environments/{environment}/{operation:activate|pause|resume|deactivate}", api.handleOperation)
+	r.Delete("/projects/{project}/environments/{environment}", api.handleOperation)
+}
+
+// available reports whether an operation is available on an environment in
+// its current status.
+func (api *envLifecycleAPI) available(env *mockapi.Environment, op string) bool {
+	if op != "activate" && op != "resume" && env.Name == api.s.Project.DefaultBranch {
+		return false
+	}
+	return slices.Contains(envOperations[op].from, env.Status)
+}
+
+// applyLinks sets the links for the lifecycle operations which are available
+// on each environment. The scenario's lock must be held.
+func (api *envLifecycleAPI) applyLinks() {
+	for _, env := range api.s.envs {
+		base := "/projects/" + url.PathEscape(api.s.ProjectID) + "/environments/" + url.PathEscape(env.Name)
+		for op := range envOperations {
+			if !api.available(env, op) {
+				delete(env.Links, "#"+op)
+			} else if op == "delete" {
+				env.Links["#delete"] = mockapi.HALLink{HREF: base}
+			} else {
+				env.Links["#"+op] = mockapi.HALLink{HREF: base + "/" + op}
+			}
+		}
+	}
+}
+
+// handleOperation runs a lifecycle operation, if it is available.
+func (api *envLifecycleAPI) handleOperation(w http.ResponseWriter, req *http.Request) {
+	op := chi.URLParam(req, "operation")
+	if req.Method == http.MethodDelete {
+		op = "delete"
+	}
+	s := api.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	env := s.findEnv(chi.URLParam(req, "environment"))
+	if env == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Environment not found"})
+		return
+	}
+	if !api.available(env, op) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": "Operation not available: " + op})
+		return
+	}
+	if op == "delete" {
+		s.envs = slices.DeleteFunc(s.envs, func(other *mockapi.Environment) bool {
+			return other == env
+		})
+	} else {
+		env.Status = envOperations[op].to
+	}
+	s.applyEnvLinks()
+	s.Handler.SetEnvironments(s.envs)
+	writeJSON(w, http.StatusAccepted, map[string]any{"_embedded": map[string]any{"activities": []any{}}})
+}
@@ -0,0 +1,441 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/platformsh/cli/pkg/mockapi"
+)
+
+// orgPermissions are the permissions which users can be given on an
+// organization.
+var orgPermissions = []string{"admin", "billing", "members", "plans", "projects:create", "projects:list"}
+
+// invitationFixture is an invitation to join an organization.
+type invitationFixture struct {
+	// ID defaults to "invite" followed by a number.
+	ID string
+	// OrgID defaults to the project's organization.
+	OrgID       string
+	Email       string
+	Permissions []string
+	// State is "pending" (the default), "accepted", "cancelled" or "error".
+	State     string
+	CreatedAt time.Time
+}
+
+// orgMembersAPI stands in for the organization members API. Members come from
+// the scenario's organization grants, which the scenario's lock guards.
+type orgMembersAPI struct {
+	s *scenario
+
+	mu          sync.Mutex
+	invitations []*invitationFixture
+	// results are the states of new invitations, by email address.
+	results map[string]string
+}
+
+// WithOrgMember adds a user to an organization, through a grant with the
+// given organization permissions (e.g. "admin" or "billing"), and serves
+// organization members.
+func (s *scenario) WithOrgMember(orgID, userID string, permissions ...string) *scenario {
+	s.serveOrgMembers()
+	s.addOrgMember(orgID, userID, permissions)
+	return s
+}
+
+// addOrgMember adds a grant on an organization.
+func (s *scenario) addOrgMember(orgID, userID string, permissions []string) {
+	if permissions == nil {
+		permissions = []string{}
+	}
+	s.Grants = append(s.Grants, &mockapi.UserGrant{
+		ResourceID:     orgID,
+		ResourceType:   "organization",
+		OrganizationID: orgID,
+		UserID:         userID,
+		Permissions:    permissions,
+	})
+}
+
+// WithOrgInvitations serves organization members, and adds invitations.
+//
+// A pending invitation for an email address prevents another one. New
+// invitations are pending unless a result is set with WithInvitationResult.
+func (s *scenario) WithOrgInvitations(invitations ...*invitationFixture) *scenario {
+	api := s.serveOrgMembers()
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	for _, inv := range invitations {
+		api.addInvitation(inv)
+	}
+	return s
+}
+
+// WithInvitationResult sets the state of new invitations for an email
+// address, as if the user had responded immediately. An accepted invitation
+// adds the user to the organization: the mock API's users have email
+// addresses like "<user ID>@example.com".
+func (s *scenario) WithInvitationResult(email, state string) *scenario {
+	api := s.serveOrgMembers()
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.results[email] = state
+	return s
+}
+
+func (api *orgMembersAPI) addInvitation(inv *invitationFixture) {
+	if inv.ID == "" {
+		inv.ID = "invite" + strconv.Itoa(len(api.invitations)+1)
+	}
+	if inv.OrgID == "" {
+		inv.OrgID = api.s.Project.Organization
+	}
+	if inv.Permissions == nil {
+		inv.Permissions = []string{}
+	}
+	if inv.State == "" {
+		inv.State = "pending"
+	}
+	if inv.CreatedAt.IsZero() {
+		inv.CreatedAt, _ = time.Parse(time.RFC3339, "2014-04-01T10:00:00Z")
+	}
+	api.invitations = append(api.invitations, inv)
+}
+
+// serveOrgMembers serves organization members and invitations from an
+// orgMembersAPI (once). Organizations get the "members" and "create-member"
+// links when the current user can manage members.
+func (s *scenario) serveOrgMembers() *orgMembersAPI {
+	if s.orgMembers == nil {
+		s.orgMembers = &orgMembersAPI{s: s, results: map[string]string{}}
+		s.Use(standIn(s.orgMembers.routes))
+	}
+	return s.orgMembers
+}
+
+func (api *orgMembersAPI) routes(r chi.Router) {
+	r.Get("/organizations/{organization}/members", api.handleList)
+	r.Get("/organizations/{organization}/members/{user}", api.handleGet)
+	r.Patch("/organizations/{organization}/members/{user}", api.handleUpdate)
+	r.Delete("/organizations/{organization}/members/{user}", api.handleDelete)
+	r.Post("/organizations/{organization}/invitations", api.handleCreateInvitation)
+}
+
+// applyOrgLinks sets the links and capabilities of organizations which
+// depend on stand-ins and on the current user's permissions.
+func (s *scenario) applyOrgLinks() {
+	for _, o := range s.Orgs {
+		if s.teams != nil && !slices.Contains(o.Capabilities, "teams") {
+			o.Capabilities = append(o.Capabilities, "teams")
+		}
+		orgPath := "/organizations/" + url.PathEscape(o.ID)
+		if s.orgMembers != nil {
+			if s.canManageMembers(o.ID, s.MyUserID) {
+				o.Links["members"] = mockapi.HALLink{HREF: orgPath + "/members"}
+				o.Links["create-member"] = mockapi.HALLink{HREF: orgPath + "/members"}
+			} else {
+				delete(o.Links, "members")
+				delete(o.Links, "create-member")
+			}
+		}
+		// The "orders" link depends on the billing permission.
+		if s.billing != nil {
+			if s.canManageBilling(o.ID, s.MyUserID) {
+				o.Links["orders"] = mockapi.HALLink{HREF: orgPath + "/orders"}
+			} else {
+				delete(o.Links, "orders")
+			}
+		}
+	}
+}
+
+func (s *scenario) findOrg(id string) *mockapi.Org {
+	for _, o := range s.Orgs {
+		if o.ID == id {
+			return o
+		}
+	}
+	return nil
+}
+
+// hasOrg checks if an organization exists.
+func (s *scenario) hasOrg(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.findOrg(id) != nil
+}
+
+// isOrgMember checks if a user is a member of an organization.
+func (s *scenario) isOrgMember(orgID, userID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.findOrgGrant(orgID, userID) != nil
+}
+
+// findOrgGrant returns a user's grant on an organization, if they are a member.
+func (s *scenario) findOrgGrant(orgID, userID string) *mockapi.UserGrant {
+	for _, g := range s.Grants {
+		if g.ResourceType == "organization" && g.ResourceID == orgID && g.UserID == userID {
+			return g
+		}
+	}
+	return nil
+}
+
+// isOrgAdmin checks if a user owns an organization or has admin permission on it.
+func (s *scenario) isOrgAdmin(orgID, userID string) bool {
+	if o := s.findOrg(orgID); o != nil && o.Owner == userID {
+		return true
+	}
+	g := s.findOrgGrant(orgID, userID)
+	return g != nil && slices.Contains(g.Permissions, "admin")
+}
+
+// canManageMembers checks if a user is an organization admin or has the
+// "members" permission.
+func (s *scenario) canManageMembers(orgID, userID string) bool {
+	if s.isOrgAdmin(orgID, userID) {
+		return true
+	}
+	g := s.findOrgGrant(orgID, userID)
+	return g != nil && slices.Contains(g.Permissions, "members")
+}
+
+// checkOrgPermissions writes an error response if a list contains unknown
+// organization permissions.
+func checkOrgPermissions(w http.ResponseWriter, permissions []string) bool {
+	for _, p := range permissions {
+		if !slices.Contains(orgPermissions, p) {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"message": "Invalid permission: " + p})
+			return false
+		}
+	}
+	return true
+}
+
+// memberFromRequest returns the organization member in the request's URL, or
+// writes an error response. Only members who can manage members are allowed
+// through. The scenario's lock must be held.
+func (api *orgMembersAPI) memberFromRequest(w http.ResponseWriter, req *http.Request) *mockapi.UserGrant {
+	s, orgID := api.s, chi.URLParam(req, "organization")
+	if !s.canManageMembers(orgID, s.MyUserID) {
+		writeJSON(w, http.StatusForbidden, map[string]any{"message": "You do not have permission to manage members of this organization."})
+		return nil
+	}
+	g := s.findOrgGrant(orgID, chi.URLParam(req, "user"))
+	if g == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Member not found"})
+	}
+	return g
+}
+
+func (api *orgMembersAPI) handleList(w http.ResponseWriter, req *http.Request) {
+	s, orgID := api.s, chi.URLParam(req, "organization")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.findOrg(orgID) == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Organization not found"})
+		return
+	}
+	var grants []*mockapi.UserGrant
+	for _, g := range s.Grants {
+		if g.ResourceType == "organization" && g.ResourceID == orgID {
+			grants = append(grants, g)
+		}
+	}
+	// Members are ordered by creation date, which is the order they were added.
+	if strings.HasPrefix(req.URL.Query().Get("sort"), "-") {
+		slices.Reverse(grants)
+	}
+	items := []any{}
+	var userIDs []string
+	for _, g := range grants {
+		items = append(items, api.memberData(g))
+		userIDs = append(userIDs, g.UserID)
+	}
+	writeJSON(w, http.StatusOK, collectionData(items, req, userIDs))
+}
+
+func (api *orgMembersAPI) handleGet(w http.ResponseWriter, req *http.Request) {
+	s := api.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g := s.findOrgGrant(chi.URLParam(req, "organization"), chi.URLParam(req, "user"))
+	if g == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Member not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, api.memberData(g))
+}
+
+func (api *orgMembersAPI) handleUpdate(w http.ResponseWriter, req *http.Request) {
+	var params struct {
+		Permissions []string `json:"permissions"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil || params.Permissions == nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": "Invalid member parameters"})
+		return
+	}
+	s := api.s
+	s.mu.Lock()
+	g := api.memberFromRequest(w, req)
+	if g == nil || !checkOrgPermissions(w, params.Permissions) {
+		s.mu.Unlock()
+		return
+	}
+	g.Permissions = params.Permissions
+	data := api.memberData(g)
+	s.mu.Unlock()
+	s.updateGrants()
+	writeJSON(w, http.StatusOK, data)
+}
+
+func (api *orgMembersAPI) handleDelete(w http.ResponseWriter, req *http.Request) {
+	s := api.s
+	s.mu.Lock()
+	g := api.memberFromRequest(w, req)
+	if g == nil {
+		s.mu.Unlock()
+		return
+	}
+	orgID, userID := g.ResourceID, g.UserID
+	if o := s.findOrg(orgID); o != nil && o.Owner == userID {
+		s.mu.Unlock()
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": "The organization owner cannot be removed."})
+		return
+	}
+	// Removing a member also removes their access to the organization's
+	// projects and teams.
+	s.Grants = slices.DeleteFunc(s.Grants, func(other *mockapi.UserGrant) bool {
+		return other.OrganizationID == orgID && other.UserID == userID
+	})
+	s.mu.Unlock()
+	if s.teams != nil {
+		s.teams.removeOrgMember(orgID, userID)
+	}
+	s.updateGrants()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *orgMembersAPI) handleCreateInvitation(w http.ResponseWriter, req *http.Request) {
+	var params struct {
+		Email       string   `json:"email"`
+		Permissions []string `json:"permissions"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil || params.Email == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": "Invalid invitation parameters"})
+		return
+	}
+	s, orgID := api.s, chi.URLParam(req, "organization")
+	s.mu.Lock()
+	allowed := s.canManageMembers(orgID, s.MyUserID)
+	s.mu.Unlock()
+	if !allowed {
+		writeJSON(w, http.StatusForbidden, map[string]any{"message": "You do not have permission to invite members to this organization."})
+		return
+	}
+	if !checkOrgPermissions(w, params.Permissions) {
+		return
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	for _, other := range api.invitations {
+		if other.OrgID == orgID && other.State == "pending" && strings.EqualFold(other.Email, params.Email) {
+			writeJSON(w, http.StatusConflict, map[string]any{"message": "An invitation already exists for this email address and organization."})
+			return
+		}
+	}
+	inv := &invitationFixture{
+		OrgID:       orgID,
+		Email:       params.Email,
+		Permissions: params.Permissions,
+		State:       api.results[params.Email],
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+	api.addInvitation(inv)
+	if inv.State == "accepted" {
+		userID, _, _ := strings.Cut(inv.Email, "@")
+		s.mu.Lock()
+		s.addOrgMember(orgID, userID, inv.Permissions)
+		s.mu.Unlock()
+		s.updateGrants()
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"id":              inv.ID,
+		"organization_id": inv.OrgID,
+		"email":           inv.Email,
+		"permissions":     inv.Permissions,
+		"state":           inv.State,
+		"owner":           map[string]any{"id": s.MyUserID},
+		"created_at":      inv.CreatedAt.Format(time.RFC3339),
+		"updated_at":      inv.CreatedAt.Format(time.RFC3339),
+		"finished_at":     nil,
+	})
+}
+
+// memberData returns the API representation of an organization member.
+// Members are identified by their user ID. The scenario's lock must be held.
+func (api *orgMembersAPI) memberData(g *mockapi.UserGrant) map[string]any {
+	self := "/organizations/" + url.PathEscape(g.ResourceID) + "/members/" + url.PathEscape(g.UserID)
+	created, _ := time.Parse(time.RFC3339, "2014-04-01T10:00:00Z")
+	owner := false
+	if o := api.s.findOrg(g.ResourceID); o != nil {
+		owner = o.Owner == g.UserID
+	}
+	return map[string]any{
+		"id":              g.UserID,
+		"organization_id": g.ResourceID,
+		"user_id":         g.UserID,
+		"permissions":     g.Permissions,
+		"level":           "admin",
+		"owner":           owner,
+		"created_at":      created.Format(time.RFC3339),
+		"updated_at":      created.Format(time.RFC3339),
+		"_links": map[string]any{
+			"self":    map[string]any{"href": self},
+			"#edit":   map[string]any{"href": self},
+			"#delete": map[string]any{"href": self},
+		},
+	}
+}
+
+// collectionData returns a page of items, in the format of the accounts API.
+// The page is selected by the "page[size]" and "page[after]" query
+// parameters. The userIDs, if any, are the users referenced by each item,
+// which the client resolves through a reference link.
+func collectionData(items []any, req *http.Request, userIDs []string) map[string]any {
+	q := req.URL.Query()
+	total := len(items)
+	start, _ := strconv.Atoi(q.Get("page[after]"))
+	start = min(max(start, 0), total)
+	end := total
+	if size, err := strconv.Atoi(q.Get("page[size]")); err == nil && size > 0 {
+		end = min(start+size, total)
+	}
+	links := map[string]any{
+		"self": map[string]any{"href": req.URL.String()},
+	}
+	if end < total {
+		q.Set("page[after]", strconv.Itoa(end))
+		next := url.URL{Path: req.URL.Path, RawQuery: q.Encode()}
+		links["next"] = map[string]any{"href": next.String()}
+	}
+	if userIDs != nil && end > start {
+		links["ref:users:0"] = map[string]any{"href": "/ref/users?in=" + url.QueryEscape(strings.Join(userIDs[start:end], ","))}
+	}
+	return map[string]any{
+		"items":  items[start:end],
+		"count":  total,
+		"_links": links,
+	}
+}
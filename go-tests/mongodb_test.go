@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/platformsh/cli/pkg/mockapi"
+)
+
+// mongoArchive stands in for a mongodump archive.
+const mongoArchive = "mock archive\x00\x01\x02\xff"
+
+// mongoConnectionArgs are the connection arguments for the "mongodb"
+// relationship in setupMongoTest.
+var mongoConnectionArgs = []string{
+	"--username", "main", "--password", "mongopass", "--host", "mongodb.internal", "--port", "27017",
+	"--authenticationDatabase", "main",
+}
+
+// mongoReplicaSetURI is the host of the "replicaset" relationship in
+// setupMongoTest, a MongoDB URI with multiple hosts.
+const mongoReplicaSetURI = "mongodb://mongodb-0.internal,mongodb-1.internal:27017/main?replicaSet=rs0"
+
+// setupMongoTest creates a scenario where an app has two MongoDB
+// relationships (one using a URI), and the MongoDB tools are emulated.
+func setupMongoTest(t *testing.T) *scenario {
+	replicaSet := mongodbRelationship("mongodb")
+	replicaSet.Host = mongoReplicaSetURI
+	relationships := relationshipsFixture{}.
+		With("mongodb", mongodbRelationship("mongodb")).
+		With("replicaset", replicaSet).
+		With("database", mysqlRelationship("db"))
+	return newScenario(t).
+		WithEnv("main", "production", "active", nil).
+		WithSSH("main", "app", relationships.EnvVar()).
+		WithService("main", mockapi.App{Name: "mongodb", Type: "mongodb-enterprise:7.0", Size: "M", Disk: 2048}).
+		WithMongoTools()
+}
+
+func TestMongoShell(t *testing.T) {
+	t.Parallel()
+	s := setupMongoTest(t)
+	s.Mongo.On("mongo", commandResult{Output: "42\n"})
+	f, p := s.Factory(), s.ProjectID
+
+	// A relationship must be chosen, out of those with the mongodb scheme.
+	_, stdErr, err := f.RunCombinedOutput("mongo", "-p", p, "-e", "main", "--eval", "db.users.count()")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "More than one relationship found.")
+	assert.Contains(t, stdErr, "    mongodb\n    replicaset\n")
+	assert.Empty(t, s.Mongo.Invocations())
+
+	assert.Equal(t, "42\n", f.Run("mongo", "-p", p, "-e", "main", "-r", "mongodb", "--eval", "db.users.count()"))
+	require.Len(t, s.Mongo.Invocations(), 1)
+	assert.Equal(t, mongoInvocation{
+		Tool:    "mongo",
+		Command: "mongo --username main --password mongopass --host mongodb.internal --port 27017 --authenticationDatabase main main --eval 'db.users.count()' --quiet",
+		Args:    append(append([]string{}, mongoConnectionArgs...), "main", "--eval", "db.users.count()", "--quiet"),
+	}, s.Mongo.Invocations()[0])
+
+	// A URI with multiple hosts is passed as it is.
+	s.Mongo.Reset()
+	f.Run("mongo", "-p", p, "-e", "main", "-r", "replicaset")
+	assert.Equal(t, []string{mongoReplicaSetURI, "--quiet"}, s.Mongo.Invocations()[0].Args)
+
+	// The shell's exit code is the command's exit code.
+	s.Mongo.On("mongo", commandResult{Error: "uncaught exception\n", ExitCode: 3})
+	_, stdErr, err = f.RunCombinedOutput("mongo", "-p", p, "-e", "main", "-r", "mongodb", "--eval", "quit(3)")
+	assertExitCode(t, 3, err)
+	assert.Contains(t, stdErr, "uncaught exception")
+}
+
+func TestMongoDump(t *testing.T) {
+	t.Parallel()
+	s := setupMongoTest(t)
+	s.Mongo.On("mongodump", commandResult{Output: mongoArchive})
+	f, p := s.Factory(), s.ProjectID
+
+	assert.Equal(t, mongoArchive, f.Run("mongodump", "-p", p, "-e", "main", "-r", "mongodb", "--stdout", "-c", "users", "--gzip"))
+	require.Len(t, s.Mongo.Invocations(), 1)
+	assert.Equal(t, append(append([]string{}, mongoConnectionArgs...), "--db", "main", "--collection", "users", "--archive", "--gzip"),
+		s.Mongo.Invocations()[0].Args)
+
+	// By default the archive is saved to a file.
+	s.Mongo.Reset()
+	filename := p + "--main--app--archive.bson"
+	_, stdErr, err := f.RunCombinedOutput("mongodump", "-p", p, "-e", "main", "-r", "mongodb")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Creating BSON archive file: "+filename)
+	b, err := os.ReadFile(filepath.Join(f.WorkDir(), filename))
+	require.NoError(t, err)
+	assert.Equal(t, mongoArchive, string(b))
+	assert.Equal(t, append(append([]string{}, mongoConnectionArgs...), "--db", "main", "--archive"), s.Mongo.Invocations()[0].Args)
+
+	s.Mongo.Reset()
+	f.Run("mongodump", "-p", p, "-e", "main", "-r", "replicaset", "--stdout")
+	assert.Equal(t, []string{"--uri", mongoReplicaSetURI, "--archive"}, s.Mongo.Invocations()[0].Args)
+
+	s.Mongo.On("mongodump", commandResult{Error: "Failed: can't create session: could not connect to server\n", ExitCode: 1})
+	_, stdErr, err = f.RunCombinedOutput("mongodump", "-p", p, "-e", "main", "-r", "mongodb", "--stdout")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "could not connect to server")
+}
+
+func TestMongoRestore(t *testing.T) {
+	t.Parallel()
+	s := setupMongoTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	// The archive is streamed from stdin.
+	_, _, err := f.RunWithInput(mongoArchive, "mongorestore", "-p", p, "-e", "main", "-r", "mongodb", "-c", "users")
+	require.NoError(t, err)
+	require.Len(t, s.Mongo.Invocations(), 1)
+	assert.Equal(t, mongoInvocation{
+		Tool:    "mongorestore",
+		Command: "mongorestore --username main --password mongopass --host mongodb.internal --port 27017 --authenticationDatabase main --db main --collection users --archive",
+		Args:    append(append([]string{}, mongoConnectionArgs...), "--db", "main", "--collection", "users", "--archive"),
+		Input:   mongoArchive,
+	}, s.Mongo.Invocations()[0])
+
+	s.Mongo.On("mongorestore", commandResult{Error: "Failed: stream or file does not appear to be a mongodump archive\n", ExitCode: 1})
+	_, stdErr, err := f.RunWithInput("not an archive", "mongorestore", "-p", p, "-e", "main", "-r", "mongodb")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "does not appear to be a mongodump archive")
+}
+
+func TestMongoExport(t *testing.T) {
+	t.Parallel()
+	s := setupMongoTest(t)
+	s.Mongo.On("mongoexport", commandResult{Output: "name,email\nAlice,alice@example.com\n"})
+	f, p := s.Factory(), s.ProjectID
+
+	assert.Equal(t, "name,email\nAlice,alice@example.com\n",
+		f.Run("mongoexport", "-p", p, "-e", "main", "-r", "mongodb", "-c", "users", "--type", "csv", "-f", "name", "-f", "email"))
+	require.Len(t, s.Mongo.Invocations(), 1)
+	assert.Equal(t, append(append([]string{}, mongoConnectionArgs...),
+		"--db", "main", "--collection", "users", "--type", "csv", "--fields", "name,email", "--quiet"),
+		s.Mongo.Invocations()[0].Args)
+
+	s.Mongo.Reset()
+	f.Run("mongoexport", "-p", p, "-e", "main", "-r", "replicaset", "-c", "users", "--jsonArray")
+	assert.Equal(t, []string{"--uri", mongoReplicaSetURI, "--collection", "users", "--jsonArray", "--quiet"}, s.Mongo.Invocations()[0].Args)
+
+	_, stdErr, err := f.RunCombinedOutput("mongoexport", "-p", p, "-e", "main", "-r", "mongodb", "-c", "users", "--type", "csv")
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "CSV mode requires a field list.")
+
+	_, stdErr, err = f.RunCombinedOutput("mongoexport", "-p", p, "-e", "main", "-r", "mongodb")
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "No collection specified. Use the --collection (-c) option to specify one.")
+	assert.Len(t, s.Mongo.Invocations(), 1)
+}
+
+func TestDBSizeMongoDB(t *testing.T) {
+	t.Parallel()
+	s := setupMongoTest(t)
+	s.Mongo.On("mongo", commandResult{Output: "1073741824\n"})
+	f, p := s.Factory(), s.ProjectID
+
+	stdOut, _, err := f.RunCombinedOutput("db:size", "-p", p, "-e", "main", "-r", "mongodb", "--format", "csv", "--no-header")
+	require.NoError(t, err)
+	assert.Equal(t, "2147483648,1073741824,50\n", stdOut)
+	require.Len(t, s.Mongo.Invocations(), 1)
+	assert.Equal(t, append(append([]string{}, mongoConnectionArgs...), "main", "--quiet", "--eval", "db.stats().fsUsedSize"),
+		s.Mongo.Invocations()[0].Args)
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/platformsh/cli/pkg/mockssh"
+)
+
+// mongoToolNames are the MongoDB programs which mongoTools emulates.
+var mongoToolNames = []string{"mongo", "mongodump", "mongoexport", "mongorestore"}
+
+// mongoInvocation is a run of a MongoDB tool.
+type mongoInvocation struct {
+	Tool string
+	// Command is the full command line, and Args the arguments after the
+	// tool's name, unquoted.
+	Command string
+	Args    []string
+	// Input is what mongorestore read from stdin.
+	Input string
+}
+
+// mongoTools emulates the MongoDB shell and tools over SSH. It records each
+// run, and answers with the result set by On for the tool. Archives stream
+// both ways: a mongodump result's Output is the archive, and mongorestore
+// reads one from stdin.
+type mongoTools struct {
+	mu          sync.Mutex
+	results     map[string]commandResult
+	invocations []mongoInvocation
+}
+
+// WithMongoTools emulates the MongoDB tools (see mongoTools) on the apps
+// served by WithSSH. Tests can use s.Mongo to set results and check runs.
+func (s *scenario) WithMongoTools() *scenario {
+	s.Mongo = &mongoTools{results: make(map[string]commandResult)}
+	s.handleSSHCommands(func(command string) bool {
+		return mongoToolName(command) != ""
+	}, s.Mongo.handle)
+	return s
+}
+
+// mongoToolName returns the name of the tool a command runs, if any.
+func mongoToolName(command string) string {
+	name, _, _ := strings.Cut(command, " ")
+	if slices.Contains(mongoToolNames, name) {
+		return name
+	}
+	return ""
+}
+
+// On sets the result of a tool's runs.
+func (m *mongoTools) On(tool string, result commandResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results[tool] = result
+}
+
+// Invocations returns the runs of the tools so far.
+func (m *mongoTools) Invocations() []mongoInvocation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.invocations)
+}
+
+// Reset forgets the runs so far (but not the results).
+func (m *mongoTools) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.invocations = nil
+}
+
+func (m *mongoTools) handle(_ ssh.ConnMetadata, command string, cio mockssh.CommandIO) int {
+	inv := mongoInvocation{Tool: mongoToolName(command), Command: command, Args: shellSplit(command)[1:]}
+	if inv.Tool == "mongorestore" {
+		b, _ := io.ReadAll(cio.StdIn)
+		inv.Input = string(b)
+	}
+
+	m.mu.Lock()
+	m.invocations = append(m.invocations, inv)
+	result := m.results[inv.Tool]
+	m.mu.Unlock()
+
+	_, _ = io.WriteString(cio.StdOut, result.Output)
+	_, _ = io.WriteString(cio.StdErr, result.Error)
+	return result.ExitCode
+}
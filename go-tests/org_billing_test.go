@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupOrgBillingTest creates a scenario with an organization (acme) owned by
+// the current user, with a partial billing address and profile.
+func setupOrgBillingTest(t *testing.T) *scenario {
+	return newScenario(t).
+		WithOrg("org-id-1", "acme", "ACME Inc.").
+		WithBillingAddress("org-id-1", map[string]string{
+			"country":      "GB",
+			"name_line":    "Wile E. Coyote",
+			"thoroughfare": "1 Desert Road",
+		}).
+		WithBillingProfile("org-id-1", map[string]string{
+			"company_name":    "ACME Inc.",
+			"billing_contact": "billing@example.com",
+		})
+}
+
+func TestOrgBillingAddress(t *testing.T) {
+	t.Parallel()
+	s := setupOrgBillingTest(t)
+	f := s.Factory()
+
+	assertTrimmed(t, "GB", f.Run("org:billing:address", "-o", "acme", "country"))
+	assertTrimmed(t, "1 Desert Road", f.Run("org:billing:address", "-o", "acme", "thoroughfare"))
+
+	// Multiple properties can be updated at once.
+	stdOut, stdErr, err := f.RunCombinedOutput("org:billing:address", "-o", "acme",
+		"locality", "London", "postal_code", "SW1A 1AA", "--format", "plain", "--columns", "country,locality,postal_code")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, `Updating the address with values: {"locality":"London","postal_code":"SW1A 1AA"}`)
+	assert.Equal(t, "country\tlocality\tpostal_code\nGB\tLondon\tSW1A 1AA\n", stdOut)
+	s.Recorder.AssertOneJSON(t, "PATCH", "/organizations/org-id-1/address", `{"locality": "London", "postal_code": "SW1A 1AA"}`)
+
+	_, stdErr, err = f.RunCombinedOutput("org:billing:address", "-o", "acme", "locality", "London", "country", "GB")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "There are no changes to make.")
+	s.Recorder.AssertCount(t, 1, "PATCH", "/organizations/org-id-1/address")
+
+	// The API validates country codes against the same list as org:create.
+	_, stdErr, err = f.RunCombinedOutput("org:billing:address", "-o", "acme", "country", "XY")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Invalid value for country: Invalid country code: XY")
+
+	_, stdErr, err = f.RunCombinedOutput("org:billing:address", "-o", "acme", "country", "FR", "planet", "Earth")
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "Property not writable: planet")
+	assert.Contains(t, stdErr, "Invalid value for planet: Earth")
+	s.Recorder.AssertCount(t, 2, "PATCH", "/organizations/org-id-1/address")
+
+	_, stdErr, err = f.RunCombinedOutput("org:billing:address", "-o", "acme", "country", "FR", "locality")
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "Invalid number of property/value pair arguments")
+
+	assertTrimmed(t, "GB", f.Run("org:billing:address", "-o", "acme", "country"))
+}
+
+func TestOrgBillingProfile(t *testing.T) {
+	t.Parallel()
+	s := setupOrgBillingTest(t)
+	f := s.Factory()
+
+	assertTrimmed(t, "billing@example.com", f.Run("org:billing:profile", "-o", "acme", "billing_contact"))
+
+	assertTrimmed(t, `
+company_name	vat_number
+ACME Inc.
+`, f.Run("org:billing:profile", "-o", "acme", "--format", "plain", "--columns", "company_name,vat_number"))
+
+	_, stdErr, err := f.RunCombinedOutput("org:billing:profile", "-o", "acme", "vat_number", "GB123456789")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Property vat_number set to: GB123456789")
+	s.Recorder.AssertOneJSON(t, "PATCH", "/organizations/org-id-1/profile", `{"vat_number": "GB123456789"}`)
+	assertTrimmed(t, "GB123456789", f.Run("org:billing:profile", "-o", "acme", "vat_number"))
+
+	_, stdErr, err = f.RunCombinedOutput("org:billing:profile", "-o", "acme", "vat_number", "GB123456789")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "Property vat_number already set as: GB123456789")
+	s.Recorder.AssertCount(t, 1, "PATCH", "/organizations/org-id-1/profile")
+
+	_, stdErr, err = f.RunCombinedOutput("org:billing:profile", "-o", "acme", "billing_contact", "accounts")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Invalid value for billing_contact: This value is not a valid email address.")
+	assertTrimmed(t, "billing@example.com", f.Run("org:billing:profile", "-o", "acme", "billing_contact"))
+
+	_, stdErr, err = f.RunCombinedOutput("org:billing:profile", "-o", "acme", "id", "other")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Property not writable: id")
+	s.Recorder.AssertCount(t, 2, "PATCH", "/organizations/org-id-1/profile")
+}
+
+func TestOrgBillingPermission(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t)
+	// The current user can manage billing in one organization (hooli), and
+	// only members in another (initech).
+	s.Orgs = append(s.Orgs,
+		makeOrg("org-id-2", "hooli", "Hooli", "owner-id", "flexible"),
+		makeOrg("org-id-3", "initech", "Initech", "owner-id", "flexible"))
+	s.Project.Organization = "org-id-3"
+	s.WithOrgMember("org-id-2", s.MyUserID, "billing").
+		WithOrgMember("org-id-3", s.MyUserID, "members").
+		WithBillingProfile("org-id-2", map[string]string{"company_name": "Hooli XYZ"}).
+		WithBillingProfile("org-id-3", map[string]string{"company_name": "Initech LLC"})
+	f := s.Factory()
+
+	assertTrimmed(t, "Hooli XYZ", f.Run("org:billing:profile", "-o", "hooli", "company_name"))
+
+	_, stdErr, err := f.RunCombinedOutput("org:billing:profile", "-o", "initech", "company_name")
+	assertExitCode(t, 6, err)
+	assert.Contains(t, stdErr, "Permission denied.")
+
+	_, stdErr, err = f.RunCombinedOutput("org:billing:address", "-o", "initech", "country", "US")
+	assertExitCode(t, 6, err)
+	assert.Contains(t, stdErr, "Permission denied.")
+	s.Recorder.AssertNone(t, "PATCH", "/organizations/org-id-3/address")
+}
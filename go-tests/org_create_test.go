@@ -10,6 +10,7 @@ import (
 )
 
 func TestOrgCreate(t *testing.T) {
+	t.Parallel()
 	authServer := mockapi.NewAuthServer(t)
 	defer authServer.Close()
 
@@ -26,9 +27,6 @@ func TestOrgCreate(t *testing.T) {
 
 	f := newCommandFactory(t, apiServer.URL, authServer.URL)
 
-	// TODO disable the cache?
-	f.Run("cc")
-
 	assertTrimmed(t, `
 +------+-----------+----------+--------------------------------------+
 | Name | Label     | Type     | Owner email                          |
@@ -10,6 +10,7 @@ import (
 )
 
 func TestOrgInfo(t *testing.T) {
+	t.Parallel()
 	authServer := mockapi.NewAuthServer(t)
 	defer authServer.Close()
 
@@ -2,13 +2,13 @@ package tests
 
 import (
 	"net/http/httptest"
-	"net/url"
 	"testing"
 
 	"github.com/platformsh/cli/pkg/mockapi"
 )
 
 func TestOrgList(t *testing.T) {
+	t.Parallel()
 	authServer := mockapi.NewAuthServer(t)
 	defer authServer.Close()
 
@@ -50,15 +50,3 @@ org-id-3,duff
 org-id-2,four-seasons
 `, f.Run("orgs", "--format", "csv", "--columns", "id,name", "--no-header"))
 }
-
-func makeOrg(id, name, label, owner, typ string) *mockapi.Org {
-	return &mockapi.Org{
-		ID:           id,
-		Type:         typ,
-		Name:         name,
-		Label:        label,
-		Owner:        owner,
-		Capabilities: []string{},
-		Links:        mockapi.MakeHALLinks("self=/organizations/" + url.PathEscape(id)),
-	}
-}
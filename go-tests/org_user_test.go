@@ -0,0 +1,228 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/platformsh/cli/pkg/mockapi"
+)
+
+// setupOrgUserTest creates a scenario with an organization (acme) owned by
+// the current user, two other members and a pending invitation.
+func setupOrgUserTest(t *testing.T) *scenario {
+	return newScenario(t).
+		WithOrg("org-id-1", "acme", "ACME Inc.").
+		WithOrgMember("org-id-1", "user-id-2", "billing", "members").
+		WithOrgMember("org-id-1", "user-id-3").
+		WithOrgInvitations(&invitationFixture{Email: "pending@example.com", Permissions: []string{"billing"}})
+}
+
+func TestOrgUserList(t *testing.T) {
+	t.Parallel()
+	s := setupOrgUserTest(t)
+	f := s.Factory()
+
+	assertTrimmed(t, `
++------------+------------------------+--------+------------------+
+| ID         | Email                  | Owner? | Permissions      |
++------------+------------------------+--------+------------------+
+| my-user-id | my-user-id@example.com | true   | admin            |
+| user-id-2  | user-id-2@example.com  | false  | billing, members |
+| user-id-3  | user-id-3@example.com  | false  |                  |
++------------+------------------------+--------+------------------+
+`, f.Run("org:users", "-o", "acme"))
+
+	stdOut, stdErr, err := f.RunCombinedOutput("org:users", "-o", "acme", "--count", "2", "--reverse", "--format", "plain", "--columns", "id")
+	require.NoError(t, err)
+	assert.Equal(t, "ID\nuser-id-3\nuser-id-2\n", stdOut)
+	assert.Contains(t, stdErr, "More users are available (displaying 2, total 3)")
+
+	assert.Equal(t, "ID\nmy-user-id\nuser-id-2\nuser-id-3\n",
+		f.Run("org:users", "-o", "acme", "--count", "0", "--format", "plain", "--columns", "id"))
+
+	assertTrimmed(t, "billing, members", f.Run("org:user:get", "-o", "acme", "user-id-2@example.com", "-P", "permissions"))
+	assertTrimmed(t, "true", f.Run("org:user:get", "-o", "acme", "my-user-id@example.com", "-P", "owner"))
+
+	_, stdErr, err = f.RunCombinedOutput("org:user:get", "-o", "acme", "nobody@example.com")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "User not found: nobody@example.com")
+}
+
+func TestOrgUserAdd(t *testing.T) {
+	t.Parallel()
+	s := setupOrgUserTest(t).
+		WithInvitationResult("user-id-4@example.com", "accepted").
+		WithInvitationResult("cancelled@example.com", "cancelled").
+		WithInvitationResult("error@example.com", "error")
+	f := s.Factory()
+
+	// Permissions can be repeated or separated by commas.
+	_, stdErr, err := f.RunCombinedOutput("org:user:add", "-o", "acme", "new@example.com", "--permission", "billing,projects:list", "--permission", "plans")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "The user has been successfully invited to the organization.")
+	s.Recorder.AssertOneJSON(t, "POST", "/organizations/org-id-1/invitations",
+		`{"email": "new@example.com", "permissions": ["billing", "projects:list", "plans"]}`)
+
+	// Only one invitation can be pending for each email address.
+	for _, email := range []string{"new@example.com", "pending@example.com"} {
+		_, stdErr, err = f.RunCombinedOutput("org:user:add", "-o", "acme", email, "--permission", "billing")
+		assert.Error(t, err)
+		assert.Contains(t, stdErr, "An invitation already exists for this email address")
+	}
+
+	// Permissions given with --permission are not checked by the CLI (only
+	// those entered interactively are): this covers passing them to the API,
+	// and showing its error.
+	s.Recorder.Reset()
+	_, stdErr, err = f.RunCombinedOutput("org:user:add", "-o", "acme", "new@example.com", "--permission", "superpowers")
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "Invalid permission: superpowers")
+	s.Recorder.AssertOneJSON(t, "POST", "/organizations/org-id-1/invitations",
+		`{"email": "new@example.com", "permissions": ["superpowers"]}`)
+
+	s.Recorder.Reset()
+	_, stdErr, err = f.RunCombinedOutput("org:user:add", "-o", "acme", "user-id-2@example.com", "--permission", "billing")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The user user-id-2@example.com already exists on the organization ACME Inc. (acme)")
+	assert.Contains(t, stdErr, "To update the user, run: platform-test org:user:update user-id-2@example.com")
+
+	_, stdErr, err = f.RunCombinedOutput("org:user:add", "-o", "acme", "not-an-email")
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "Invalid email address: not-an-email")
+	s.Recorder.AssertNone(t, "POST", "/invitations")
+
+	// Invitations can be accepted, cancelled or fail immediately.
+	_, stdErr, err = f.RunCombinedOutput("org:user:add", "-o", "acme", "user-id-4@example.com", "--permission", "projects:create")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "The user has been successfully added to the organization.")
+	assertTrimmed(t, "projects:create", f.Run("org:user:get", "-o", "acme", "user-id-4@example.com", "-P", "permissions"))
+
+	_, stdErr, err = f.RunCombinedOutput("org:user:add", "-o", "acme", "cancelled@example.com")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The invitation invite4 was cancelled.")
+
+	_, stdErr, err = f.RunCombinedOutput("org:user:add", "-o", "acme", "error@example.com")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The invitation invite5 errored.")
+}
+
+func TestOrgUserUpdate(t *testing.T) {
+	t.Parallel()
+	s := setupOrgUserTest(t)
+	f := s.Factory()
+
+	_, stdErr, err := f.RunCombinedOutput("org:user:update", "-o", "acme", "user-id-3@example.com", "--permission", "billing,members")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Updating the user user-id-3@example.com on the organization ACME Inc. (acme)")
+	assert.Contains(t, stdErr, "Summary of changes:\n  Permissions:\n    + billing\n    + members\n")
+	assert.Contains(t, stdErr, "The user's permissions are now: billing, members")
+	s.Recorder.AssertOneJSON(t, "PATCH", "/organizations/org-id-1/members/user-id-3", `{"permissions": ["billing", "members"]}`)
+
+	_, stdErr, err = f.RunCombinedOutput("org:user:update", "-o", "acme", "user-id-2@example.com", "--permission", "billing")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Summary of changes:\n  Permissions:\n      billing\n    - members\n")
+	assertTrimmed(t, "billing", f.Run("org:user:get", "-o", "acme", "user-id-2@example.com", "-P", "permissions"))
+
+	s.Recorder.Reset()
+	_, stdErr, err = f.RunCombinedOutput("org:user:update", "-o", "acme", "user-id-2@example.com", "--permission", "billing")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "The user's permissions are already set to: billing")
+
+	_, stdErr, err = f.RunCombinedOutput("org:user:update", "-o", "acme", "my-user-id@example.com", "--permission", "billing")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The user is the owner of the organization, so does not need permissions.")
+
+	_, stdErr, err = f.RunCombinedOutput("org:user:update", "-o", "acme", "nobody@example.com", "--permission", "billing")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The user nobody@example.com was not found in the organization ACME Inc. (acme)")
+	s.Recorder.AssertNone(t, "PATCH", "/members/user-id-2")
+}
+
+func TestOrgUserDelete(t *testing.T) {
+	t.Parallel()
+	s := setupOrgUserTest(t)
+	s.Grants = append(s.Grants, &mockapi.UserGrant{
+		ResourceID:     s.ProjectID,
+		ResourceType:   "project",
+		OrganizationID: "org-id-1",
+		UserID:         "user-id-3",
+		Permissions:    []string{"viewer", "development:contributor"},
+	})
+	f, p := s.Factory(), s.ProjectID
+
+	assert.Contains(t, f.Run("users", "-p", p, "--format", "plain", "--columns", "id"), "user-id-3")
+
+	_, stdErr, err := f.RunCombinedOutput("org:user:delete", "-o", "acme", "user-id-3@example.com")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "The user was successfully deleted.")
+	s.Recorder.AssertCount(t, 1, "DELETE", "/organizations/org-id-1/members/user-id-3")
+
+	// The user loses access to the organization's projects.
+	assert.NotContains(t, f.Run("org:users", "-o", "acme", "--format", "plain", "--columns", "id"), "user-id-3")
+	assert.NotContains(t, f.Run("users", "-p", p, "--format", "plain", "--columns", "id"), "user-id-3")
+
+	_, stdErr, err = f.RunCombinedOutput("org:user:delete", "-o", "acme", "user-id-3@example.com")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "User not found: user-id-3@example.com")
+
+	_, stdErr, err = f.RunCombinedOutput("org:user:delete", "-o", "acme", "my-user-id@example.com")
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "The organization owner cannot be removed.")
+	assert.Contains(t, f.Run("org:users", "-o", "acme", "--format", "plain", "--columns", "id"), "my-user-id")
+}
+
+func TestOrgUserProjects(t *testing.T) {
+	t.Parallel()
+	s := setupOrgUserTest(t).WithOrgMember("org-id-1", "user-id-5")
+	s.Grants = append(s.Grants, &mockapi.UserGrant{
+		ResourceID:     s.ProjectID,
+		ResourceType:   "project",
+		OrganizationID: "org-id-1",
+		UserID:         "user-id-3",
+		Permissions:    []string{"viewer", "development:contributor"},
+	})
+	// Access can also come from a team.
+	s.WithTeams(&teamFixture{
+		Label:              "Admins",
+		ProjectPermissions: []string{"admin"},
+		Members:            []string{"user-id-2"},
+		Projects:           []string{s.ProjectID},
+	})
+	f, p := s.Factory(), s.ProjectID
+
+	assertTrimmed(t, "Project ID\tRole(s)\n"+p+"\t[\"viewer\",\"development:contributor\"]",
+		f.Run("org:user:projects", "-o", "acme", "user-id-3@example.com", "--format", "plain", "--columns", "project_id,roles"))
+
+	assertTrimmed(t, "Project ID\tRole(s)\n"+p+"\t[\"admin\"]",
+		f.Run("oups", "-o", "acme", "user-id-2@example.com", "--format", "plain", "--columns", "project_id,roles"))
+
+	_, stdErr, err := f.RunCombinedOutput("org:user:projects", "-o", "acme", "user-id-5@example.com")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "No projects were found for the user")
+
+	_, stdErr, err = f.RunCombinedOutput("org:user:projects", "-o", "acme", "nobody@example.com")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "User not found for email address: nobody@example.com")
+}
+
+func TestOrgUserNonAdmin(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t)
+	// The current user can only manage billing in the organization.
+	s.Orgs = append(s.Orgs, makeOrg("org-id-2", "hooli", "Hooli", "owner-id", "flexible"))
+	s.Project.Organization = "org-id-2"
+	s.WithOrgMember("org-id-2", "owner-id").
+		WithOrgMember("org-id-2", s.MyUserID, "billing")
+	f := s.Factory()
+
+	_, stdErr, err := f.RunCombinedOutput("org:users", "-o", "hooli")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "You do not have permission to view users in the organization Hooli (hooli).")
+
+	_, stdErr, err = f.RunCombinedOutput("org:user:projects", "-o", "hooli", "owner-id@example.com")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "You do not have permission to view users in the organization Hooli (hooli).")
+	s.Recorder.AssertNone(t, "GET", "/organizations/org-id-2/members")
+}
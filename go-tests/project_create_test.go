@@ -13,6 +13,7 @@ import (
 )
 
 func TestProjectCreate(t *testing.T) {
+	t.Parallel()
 	authServer := mockapi.NewAuthServer(t)
 	defer authServer.Close()
 
@@ -48,7 +49,47 @@ func TestProjectCreate(t *testing.T) {
 	f.Run("subscription:info", "-p", projectID)
 }
 
+func TestProjectCreateInteractive(t *testing.T) {
+	t.Parallel()
+	authServer := mockapi.NewAuthServer(t)
+	defer authServer.Close()
+
+	apiHandler := mockapi.NewHandler(t)
+	apiHandler.SetOrgs([]*mockapi.Org{
+		makeOrg("cli-test-id", "cli-tests", "CLI Test Org", "my-user-id", "flexible"),
+	})
+
+	apiServer := httptest.NewServer(apiHandler)
+	defer apiServer.Close()
+
+	f := newCommandFactory(t, apiServer.URL, authServer.URL)
+
+	s := f.RunInteractive("project:create", "--org", "cli-tests")
+	s.Expect("Project title")
+	s.SendLine("Interactive Project")
+	s.Expect("Region")
+	s.SendLine("test-region")
+	s.Expect("Default branch")
+	s.SendLine("")
+	s.Expect("The estimated monthly cost of this project is: $1,000 USD")
+	s.Expect("Are you sure you want to continue? [Y/n]")
+	s.SendLine("y")
+	s.Expect("Project title: Interactive Project")
+	s.Expect("Region: test-region")
+	require.NoError(t, s.Wait())
+
+	// Declining the cost confirmation should not create a project.
+	s = f.RunInteractive("project:create", "--org", "cli-tests", "--title", "Declined", "--region", "test-region")
+	s.Expect("Default branch")
+	s.SendLine("")
+	s.Expect("Are you sure you want to continue? [Y/n]")
+	s.SendLine("n")
+	assert.Error(t, s.Wait())
+	assert.NotContains(t, s.Transcript(), "Project ID:")
+}
+
 func TestProjectCreate_CanCreateError(t *testing.T) {
+	t.Parallel()
 	cases := []struct {
 		orgName            string
 		canCreateResponse  *mockapi.CanCreateResponse
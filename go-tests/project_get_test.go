@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectGet(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil).
+		WithGitRepository()
+	rev := s.Git.Seed(s.ProjectID, "main", map[string]string{"README.md": "# Test project\n"})
+	f := s.Factory()
+
+	_, stdErr, err := f.RunCombinedOutput("project:get", s.ProjectID, "project")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Downloading project")
+	assert.Contains(t, stdErr, "was successfully downloaded to")
+
+	dir := filepath.Join(f.WorkDir(), "project")
+	assert.Equal(t, rev, runGit(t, dir, "rev-parse", "HEAD"))
+	assert.Equal(t, "main", runGit(t, dir, "branch", "--show-current"))
+	assert.Equal(t, s.Git.URL(s.ProjectID), runGit(t, dir, "config", "remote.platform-test.url"))
+
+	projectConfig, err := os.ReadFile(filepath.Join(dir, ".platform", "local", "project.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(projectConfig), "id: "+s.ProjectID)
+
+	// The destination must not exist.
+	_, stdErr, err = f.RunCombinedOutput("project:get", s.ProjectID, "project")
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "The destination path already exists")
+}
+
+func TestProjectGetEmptyRepository(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil).
+		WithGitRepository()
+	f := s.Factory()
+
+	_, stdErr, err := f.RunCombinedOutput("project:get", s.ProjectID, "project")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Your project has been initialized and connected to Platform.sh Testing!")
+	assert.Contains(t, stdErr, "Commit and push to the main branch of the platform-test Git remote")
+
+	dir := filepath.Join(f.WorkDir(), "project")
+	assert.Equal(t, "main", runGit(t, dir, "branch", "--show-current"))
+	assert.Equal(t, s.Git.URL(s.ProjectID), runGit(t, dir, "config", "remote.platform-test.url"))
+}
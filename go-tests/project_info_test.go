@@ -14,6 +14,7 @@ import (
 )
 
 func TestProjectInfo(t *testing.T) {
+	t.Parallel()
 	authServer := mockapi.NewAuthServer(t)
 	defer authServer.Close()
 
@@ -2,13 +2,13 @@ package tests
 
 import (
 	"net/http/httptest"
-	"net/url"
 	"testing"
 
 	"github.com/platformsh/cli/pkg/mockapi"
 )
 
 func TestProjectList(t *testing.T) {
+	t.Parallel()
 	authServer := mockapi.NewAuthServer(t)
 	defer authServer.Close()
 
@@ -111,14 +111,3 @@ project-id-2
 project-id-3
 `, f.Run("pro", "-v", "--pipe"))
 }
-
-func makeProject(id, org, vendor, title, region string) *mockapi.Project {
-	return &mockapi.Project{
-		ID:           id,
-		Organization: org,
-		Vendor:       vendor,
-		Title:        title,
-		Region:       region,
-		Links:        mockapi.MakeHALLinks("self=/projects/" + url.PathEscape(id)),
-	}
-}
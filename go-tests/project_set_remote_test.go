@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectSetRemote(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil).
+		WithGitRepository()
+	dir := t.TempDir()
+	runGit(t, dir, "init", "--quiet", "--initial-branch=main")
+	f := s.Factory().InDir(dir)
+
+	_, stdErr, err := f.RunCombinedOutput("project:set-remote", s.ProjectID)
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "The remote project for this repository is now set to")
+	assert.Equal(t, s.Git.URL(s.ProjectID), runGit(t, dir, "config", "remote.platform-test.url"))
+
+	configFile := filepath.Join(dir, ".platform", "local", "project.yaml")
+	projectConfig, err := os.ReadFile(configFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(projectConfig), "id: "+s.ProjectID)
+
+	_, stdErr, err = f.RunCombinedOutput("project:set-remote", s.ProjectID)
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "The remote project for this repository is already set as")
+
+	_, stdErr, err = f.RunCombinedOutput("project:set-remote", "-")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "This repository is no longer mapped to a project.")
+	assert.NoFileExists(t, configFile)
+
+	// Outside a Git repository.
+	_, stdErr, err = s.Factory().RunCombinedOutput("project:set-remote", s.ProjectID)
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "No Git repository found.")
+}
@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/stretchr/testify/require"
+)
+
+// ptyTimeout is how long to wait for expected output from an interactive command.
+const ptyTimeout = 30 * time.Second
+
+// ansiPattern matches ANSI escape sequences (colors and cursor movements).
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]|\x1b[78]`)
+
+// ptySession is a CLI process attached to a pseudo-terminal.
+type ptySession struct {
+	t   *testing.T
+	cmd *exec.Cmd
+	pty *os.File
+
+	mu       sync.Mutex
+	output   bytes.Buffer
+	offset   int
+	updated  chan struct{}
+	readDone chan struct{}
+}
+
+// RunInteractive starts a command attached to a pseudo-terminal, so that it
+// asks questions as it would for a real user. Use Expect and SendLine to
+// answer prompts, and Wait to get the result.
+func (f *cmdFactory) RunInteractive(args ...string) *ptySession {
+	if runtime.GOOS == "windows" {
+		f.t.Skip("skipping interactive test: pseudo-terminals are not supported on Windows")
+	}
+	cmd := f.buildCommand(args...)
+	cmd.Stdout, cmd.Stderr = nil, nil
+	env := cmd.Env[:0]
+	for _, v := range cmd.Env {
+		if !strings.HasPrefix(v, EnvPrefix+"NO_INTERACTION=") {
+			env = append(env, v)
+		}
+	}
+	cmd.Env = env
+
+	f.t.Log("Running interactively:", cmd)
+	ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: 120, Rows: 40})
+	require.NoError(f.t, err)
+
+	s := &ptySession{
+		t:        f.t,
+		cmd:      cmd,
+		pty:      ptmx,
+		updated:  make(chan struct{}, 1),
+		readDone: make(chan struct{}),
+	}
+	go s.read()
+	f.t.Cleanup(func() {
+		if cmd.ProcessState == nil {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+		}
+		_ = ptmx.Close()
+	})
+
+	return s
+}
+
+func (s *ptySession) read() {
+	defer close(s.readDone)
+	buf := make([]byte, 4096)
+	for {
+		n, err := s.pty.Read(buf)
+		if n > 0 {
+			s.mu.Lock()
+			s.output.Write(buf[:n])
+			s.mu.Unlock()
+			select {
+			case s.updated <- struct{}{}:
+			default:
+			}
+		}
+		// Reading fails (with EIO on Linux) once the process has exited.
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Expect waits until the text appears in the output, after the previous match.
+func (s *ptySession) Expect(text string) {
+	s.t.Helper()
+	timeout := time.After(ptyTimeout)
+	for {
+		if s.advance(text) {
+			return
+		}
+		select {
+		case <-s.updated:
+		case <-s.readDone:
+			// Check one last time, in case output arrived before the process exited.
+			if s.advance(text) {
+				return
+			}
+			s.t.Fatalf("The command exited before printing %q. Transcript:\n%s", text, s.Transcript())
+		case <-timeout:
+			s.t.Fatalf("Timed out waiting for %q. Transcript:\n%s", text, s.Transcript())
+		}
+	}
+}
+
+// advance moves the offset past the next occurrence of the text, if found.
+func (s *ptySession) advance(text string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	raw := s.output.String()[s.offset:]
+	if !strings.Contains(normalizeTerminalOutput(raw), text) {
+		return false
+	}
+	// Find the shortest raw prefix that contains the match.
+	for n := len(text); n <= len(raw); n++ {
+		if strings.Contains(normalizeTerminalOutput(raw[:n]), text) {
+			s.offset += n
+			break
+		}
+	}
+	return true
+}
+
+// SendLine types a line of input, followed by Enter.
+func (s *ptySession) SendLine(line string) {
+	s.t.Helper()
+	_, err := s.pty.WriteString(line + "\n")
+	require.NoError(s.t, err)
+}
+
+// Wait waits for the command to exit and returns its error, if any.
+func (s *ptySession) Wait() error {
+	s.t.Helper()
+	err := s.cmd.Wait()
+	select {
+	case <-s.readDone:
+	case <-time.After(ptyTimeout):
+		s.t.Errorf("Timed out waiting for the terminal output to close")
+	}
+	if testing.Verbose() {
+		s.t.Logf("Transcript:\n%s", s.Transcript())
+	}
+	return err
+}
+
+// Transcript returns all output so far, as a user would see it, without
+// escape sequences and with Unix line endings.
+func (s *ptySession) Transcript() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return normalizeTerminalOutput(s.output.String())
+}
+
+func normalizeTerminalOutput(s string) string {
+	s = ansiPattern.ReplaceAllString(s, "")
+	return strings.ReplaceAll(s, "\r\n", "\n")
+}
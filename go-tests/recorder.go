@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordedRequest is a copy of an HTTP request received by a mock server.
+type recordedRequest struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// JSON decodes the request body.
+func (r recordedRequest) JSON(t *testing.T) map[string]any {
+	t.Helper()
+	var data map[string]any
+	require.NoError(t, json.Unmarshal(r.Body, &data), "decoding the body of %s %s", r.Method, r.Path)
+	return data
+}
+
+func (r recordedRequest) String() string {
+	return r.Method + " " + r.Path
+}
+
+// requestRecorder is an http.Handler middleware which captures every request
+// before passing it on, so that tests can check what the CLI sent.
+type requestRecorder struct {
+	next http.Handler
+
+	mu       sync.Mutex
+	requests []recordedRequest
+}
+
+func newRequestRecorder(next http.Handler) *requestRecorder {
+	return &requestRecorder{next: next}
+}
+
+// recordServer replaces a test server which is already started, such as the
+// one returned by mockapi.NewAuthServer, with a new server for its handler
+// which records requests. The original server is closed.
+func recordServer(t *testing.T, server *httptest.Server) (*httptest.Server, *requestRecorder) {
+	handler := server.Config.Handler
+	server.Close()
+	rec := newRequestRecorder(handler)
+	recorded := httptest.NewServer(rec)
+	t.Cleanup(recorded.Close)
+	return recorded, rec
+}
+
+func (rec *requestRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_ = req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	rec.mu.Lock()
+	rec.requests = append(rec.requests, recordedRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.Query(),
+		Header: req.Header.Clone(),
+		Body:   body,
+	})
+	rec.mu.Unlock()
+
+	rec.next.ServeHTTP(w, req)
+}
+
+// Requests returns all the recorded requests, in order.
+func (rec *requestRecorder) Requests() []recordedRequest {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]recordedRequest(nil), rec.requests...)
+}
+
+// Reset forgets the requests recorded so far.
+func (rec *requestRecorder) Reset() {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.requests = nil
+}
+
+// Find returns the recorded requests matching a method and a path.
+//
+// The path matches if it is equal to the given path, or if it ends with it
+// (e.g. "/variables/env:TEST"). An empty method matches any method.
+func (rec *requestRecorder) Find(method, path string) []recordedRequest {
+	var found []recordedRequest
+	for _, r := range rec.Requests() {
+		if method != "" && r.Method != method {
+			continue
+		}
+		if r.Path == path || strings.HasSuffix(r.Path, path) {
+			found = append(found, r)
+		}
+	}
+	return found
+}
+
+// AssertCount asserts the number of requests matching a method and a path.
+func (rec *requestRecorder) AssertCount(t *testing.T, expected int, method, path string) bool {
+	t.Helper()
+	return assert.Len(t, rec.Find(method, path), expected, "number of %s requests to %s, in: %v", method, path, rec.Requests())
+}
+
+// AssertNone asserts that no request matched a method and a path.
+func (rec *requestRecorder) AssertNone(t *testing.T, method, path string) bool {
+	t.Helper()
+	return rec.AssertCount(t, 0, method, path)
+}
+
+// RequireOne requires exactly one request to match a method and a path, and returns it.
+func (rec *requestRecorder) RequireOne(t *testing.T, method, path string) recordedRequest {
+	t.Helper()
+	found := rec.Find(method, path)
+	require.Len(t, found, 1, "number of %s requests to %s, in: %v", method, path, rec.Requests())
+	return found[0]
+}
+
+// AssertOneJSON asserts that exactly one request matched a method and a path,
+// and that its body is equivalent to the expected JSON.
+func (rec *requestRecorder) AssertOneJSON(t *testing.T, method, path, expectedJSON string) bool {
+	t.Helper()
+	found := rec.Find(method, path)
+	if !assert.Len(t, found, 1, "number of %s requests to %s, in: %v", method, path, rec.Requests()) {
+		return false
+	}
+	return assert.JSONEq(t, expectedJSON, string(found[0].Body), "body of %s", found[0])
+}
@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"maps"
+)
+
+// relationship is an instance of a service, as listed in the
+// PLATFORM_RELATIONSHIPS variable. The constructors below (e.g.
+// mysqlRelationship) fill in the usual values for each service type, and
+// tests can change fields to make other instances, such as replicas.
+type relationship struct {
+	Service  string
+	Type     string
+	Rel      string
+	Scheme   string
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Path     string
+	Query    map[string]any
+	Public   bool
+}
+
+func mysqlRelationship(service string) relationship {
+	return relationship{Service: service, Type: "mariadb:10.6", Rel: "mysql", Scheme: "mysql",
+		Host: service + ".internal", Port: 3306, Username: "user", Path: "main",
+		Query: map[string]any{"is_master": true}}
+}
+
+func postgresqlRelationship(service string) relationship {
+	return relationship{Service: service, Type: "postgresql:15", Rel: "postgresql", Scheme: "pgsql",
+		Host: service + ".internal", Port: 5432, Username: "main", Password: "pgpass", Path: "main"}
+}
+
+func redisRelationship(service string) relationship {
+	return relationship{Service: service, Type: "redis:7.2", Rel: "redis", Scheme: "redis",
+		Host: service + ".internal", Port: 6379}
+}
+
+func valkeyRelationship(service string) relationship {
+	return relationship{Service: service, Type: "valkey:8.0", Rel: "valkey", Scheme: "valkey",
+		Host: service + ".internal", Port: 6379}
+}
+
+func mongodbRelationship(service string) relationship {
+	return relationship{Service: service, Type: "mongodb-enterprise:7.0", Rel: "mongodb", Scheme: "mongodb",
+		Host: service + ".internal", Port: 27017, Username: "main", Password: "mongopass", Path: "main"}
+}
+
+func elasticsearchRelationship(service string) relationship {
+	return relationship{Service: service, Type: "elasticsearch:8.5", Rel: "elasticsearch", Scheme: "http",
+		Host: service + ".internal", Port: 9200}
+}
+
+func rabbitmqRelationship(service string) relationship {
+	return relationship{Service: service, Type: "rabbitmq:3.13", Rel: "rabbitmq", Scheme: "amqp",
+		Host: service + ".internal", Port: 5672, Username: "guest", Password: "guest"}
+}
+
+func solrRelationship(service string) relationship {
+	return relationship{Service: service, Type: "solr:9.6", Rel: "solr", Scheme: "solr",
+		Host: service + ".internal", Port: 8080, Path: "solr/maincore"}
+}
+
+// data returns the instance as it appears in the variable, where empty
+// credentials and paths are null.
+func (r relationship) data() map[string]any {
+	nullable := func(s string) any {
+		if s == "" {
+			return nil
+		}
+		return s
+	}
+	query := map[string]any{}
+	maps.Copy(query, r.Query)
+	return map[string]any{
+		"service":  r.Service,
+		"type":     r.Type,
+		"rel":      r.Rel,
+		"scheme":   r.Scheme,
+		"host":     r.Host,
+		"port":     r.Port,
+		"username": nullable(r.Username),
+		"password": nullable(r.Password),
+		"path":     nullable(r.Path),
+		"query":    query,
+		"public":   r.Public,
+	}
+}
+
+// relationshipsFixture maps relationship names to service instances, to
+// build the PLATFORM_RELATIONSHIPS variable for a mock SSH server (see
+// WithSSH) or for commands run locally.
+type relationshipsFixture map[string][]relationship
+
+// With sets the instances of a relationship.
+func (f relationshipsFixture) With(name string, instances ...relationship) relationshipsFixture {
+	f[name] = instances
+	return f
+}
+
+// Encode returns the base64-encoded JSON value of the variable.
+func (f relationshipsFixture) Encode() string {
+	data := make(map[string][]map[string]any, len(f))
+	for name, instances := range f {
+		for _, r := range instances {
+			data[name] = append(data[name], r.data())
+		}
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// EnvVar returns the variable as a "PLATFORM_RELATIONSHIPS=..." string.
+func (f relationshipsFixture) EnvVar() string {
+	return "PLATFORM_RELATIONSHIPS=" + f.Encode()
+}
+
+// mockRelationships returns a relationship to each of the usual service
+// types, with a primary and a replica instance of the MySQL database.
+func mockRelationships() relationshipsFixture {
+	replica := mysqlRelationship("db")
+	replica.Host = "db-replica.internal"
+	replica.Query = map[string]any{"is_master": false}
+	return relationshipsFixture{}.
+		With("database", mysqlRelationship("db"), replica).
+		With("reports", postgresqlRelationship("reportsdb")).
+		With("cache", redisRelationship("cache")).
+		With("sessions", valkeyRelationship("sessions")).
+		With("mongodb", mongodbRelationship("mongodb")).
+		With("search", elasticsearchRelationship("search")).
+		With("queue", rabbitmqRelationship("queue")).
+		With("solr", solrRelationship("solr"))
+}
@@ -13,6 +13,7 @@ import (
 )
 
 func TestRouteList(t *testing.T) {
+	t.Parallel()
 	authServer := mockapi.NewAuthServer(t)
 	defer authServer.Close()
 
@@ -44,32 +45,19 @@ func TestRouteList(t *testing.T) {
 
 	f := newCommandFactory(t, apiServer.URL, authServer.URL)
 
-	assertTrimmed(t, `
-+-------------------+----------+---------------------------+
-| Route             | Type     | To                        |
-+-------------------+----------+---------------------------+
-| http://{default}  | redirect | https://main.example.com/ |
-| https://{default} | upstream | app:http                  |
-+-------------------+----------+---------------------------+
-`, f.Run("routes", "-p", projectID, "-e", ".", "--refresh"))
+	f.RunGolden("routes", "routes", "-p", projectID, "-e", ".", "--refresh")
 
 	assert.Equal(t, "upstream\n", f.Run("route:get", "-p", projectID, "-e", ".", "https://{default}", "-P", "type"))
 }
 
 func TestRouteListLocal(t *testing.T) {
+	t.Parallel()
 	f := &cmdFactory{t: t}
 	routes, err := json.Marshal(mockRoutes())
 	require.NoError(t, err)
 	f.extraEnv = []string{"PLATFORM_ROUTES=" + base64.StdEncoding.EncodeToString(routes)}
 
-	assertTrimmed(t, `
-+-------------------+----------+---------------------------+
-| Route             | Type     | To                        |
-+-------------------+----------+---------------------------+
-| http://{default}  | redirect | https://main.example.com/ |
-| https://{default} | upstream | app:http                  |
-+-------------------+----------+---------------------------+
-`, f.Run("route:list"))
+	f.RunGolden("routes", "route:list")
 
 	assert.Equal(t, "redirect\n", f.Run("route:get", "http://{default}", "--property", "type"))
 	assert.Equal(t, "https://main.example.com/\n", f.Run("route:get", "http://{default}", "--property", "to"))
@@ -0,0 +1,588 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/platformsh/cli/pkg/mockapi"
+	"github.com/platformsh/cli/pkg/mockssh"
+)
+
+// envCapability enables a feature on an environment, via its HAL links.
+type envCapability string
+
+const (
+	envActivities envCapability = "activities"
+	envDeploy     envCapability = "deploy"
+	envBackups    envCapability = "backups"
+	envVariables  envCapability = "variables"
+	envBranch     envCapability = "branch"
+	envRedeploy   envCapability = "redeploy"
+	envDomains    envCapability = "domains"
+)
+
+// envCapabilityLinks maps each capability to link names and their paths, relative to the environment.
+var envCapabilityLinks = map[envCapability]map[string]string{
+	envActivities: {"#activities": "/activities"},
+	envDeploy:     {"#deploy": "/deploy"},
+	envBackups:    {"backups": "/backups", "#backup": "/backups"},
+	envVariables:  {"#variables": "/variables", "#manage-variables": "/variables"},
+	envBranch:     {"#branch": "/branch"},
+	envRedeploy:   {"#redeploy": "/redeploy"},
+	envDomains:    {"#domains": "/domains"},
+}
+
+// scenario builds the usual test fixtures: a mock API and auth server, a
+// project (optionally in an organization) and a tree of environments with
+// their deployments. Servers are started by Factory, and closed on cleanup.
+//
+// Example:
+//
+//	s := newScenario(t).
+//		WithEnv("main", "production", "active", nil, envActivities).
+//		WithEnv("dev", "development", "active", "main")
+//	s.WithApp("main", mockapi.App{Name: "app", Type: "golang:1.23"})
+//	f := s.Factory()
+type scenario struct {
+	t *testing.T
+
+	Handler    *mockapi.Handler
+	Recorder   *requestRecorder
+	AuthServer *httptest.Server
+	APIServer  *httptest.Server
+	Git        *gitServer
+	SSH        *mockssh.Server
+	SQL        *sqlClient
+	Mongo      *mongoTools
+	Sim        *activitySimulator
+	Webhooks   *webhookReceiver
+
+	ProjectID string
+	MyUserID  string
+	Project   *mockapi.Project
+	Orgs      []*mockapi.Org
+	Grants    []*mockapi.UserGrant
+
+	// mu guards fixtures which stand-in handlers change while servers are running.
+	mu          sync.Mutex
+	envs        []*mockapi.Environment
+	activities  []*mockapi.Activity
+	ca          *testCert
+	deployments map[string]*mockapi.Deployment
+	sshHandlers map[string]mockssh.CommandHandler
+	sshCommands []sshCommandHandler
+	middleware  []func(http.Handler) http.Handler
+	factory     *cmdFactory
+
+	// Stand-ins for APIs which the mock API lacks, added by the With methods.
+	// Each guards its own fixtures, and takes its own lock before mu if it
+	// needs both.
+	capabilities  *capabilitiesAPI
+	settings      *projectSettingsAPI
+	domains       *domainsAPI
+	certificates  *certificatesAPI
+	integrations  *integrationsAPI
+	backups       *backupsAPI
+	httpAccess    *httpAccessAPI
+	lifecycle     *envLifecycleAPI
+	envSync       *envSyncAPI
+	userAccess    *userAccessAPI
+	orgMembers    *orgMembersAPI
+	teams         *teamsAPI
+	billing       *billingAPI
+	subscriptions *subscriptionsAPI
+}
+
+func newScenario(t *testing.T) *scenario {
+	projectID := mockapi.ProjectID()
+	project := makeProject(projectID, "", "test-vendor", "Project 1", "region-1")
+	project.DefaultBranch = "main"
+	project.Links = mockapi.MakeHALLinks(
+		"self=/projects/"+url.PathEscape(projectID),
+		"#edit=/projects/"+url.PathEscape(projectID),
+		"environments=/projects/"+url.PathEscape(projectID)+"/environments",
+	)
+	return &scenario{
+		t:           t,
+		Handler:     mockapi.NewHandler(t),
+		ProjectID:   projectID,
+		MyUserID:    "my-user-id",
+		Project:     project,
+		deployments: make(map[string]*mockapi.Deployment),
+	}
+}
+
+// WithOrg puts the project in an organization owned by the current user, who
+// is also granted admin access to the organization and the project.
+func (s *scenario) WithOrg(id, name, label string) *scenario {
+	s.Orgs = append(s.Orgs, makeOrg(id, name, label, s.MyUserID, "flexible"))
+	s.Project.Organization = id
+	s.Grants = append(s.Grants,
+		&mockapi.UserGrant{
+			ResourceID:     id,
+			ResourceType:   "organization",
+			OrganizationID: id,
+			UserID:         s.MyUserID,
+			Permissions:    []string{"admin"},
+		},
+		&mockapi.UserGrant{
+			ResourceID:     s.ProjectID,
+			ResourceType:   "project",
+			OrganizationID: id,
+			UserID:         s.MyUserID,
+			Permissions:    []string{"admin"},
+		},
+	)
+	return s
+}
+
+// WithEnv adds an environment, with the links for the given capabilities.
+// The parent is an environment name, or nil.
+func (s *scenario) WithEnv(name, envType, status string, parent any, capabilities ...envCapability) *scenario {
+	env := makeEnv(s.ProjectID, name, envType, status, parent)
+	s.envs = append(s.envs, env)
+	s.WithCapabilities(name, capabilities...)
+	return s
+}
+
+// WithCapabilities adds the links for capabilities to an existing environment.
+func (s *scenario) WithCapabilities(envName string, capabilities ...envCapability) *scenario {
+	env := s.Env(envName)
+	base := "/projects/" + url.PathEscape(s.ProjectID) + "/environments/" + url.PathEscape(envName)
+	for _, c := range capabilities {
+		links, ok := envCapabilityLinks[c]
+		if !ok {
+			s.t.Fatalf("Unknown environment capability: %s", c)
+		}
+		for rel, path := range links {
+			env.Links[rel] = mockapi.HALLink{HREF: base + path}
+		}
+	}
+	return s
+}
+
+// Env returns an environment added with WithEnv.
+func (s *scenario) Env(name string) *mockapi.Environment {
+	if e := s.findEnv(name); e != nil {
+		return e
+	}
+	s.t.Fatalf("Environment not found in scenario: %s", name)
+	return nil
+}
+
+// hasEnv checks if an environment exists, e.g. after it is created or deleted
+// through the API.
+func (s *scenario) hasEnv(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.findEnv(name) != nil
+}
+
+func (s *scenario) findEnv(name string) *mockapi.Environment {
+	for _, e := range s.envs {
+		if e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// WithActivity adds a project activity.
+func (s *scenario) WithActivity(a *mockapi.Activity) *scenario {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addActivity(a)
+	return s
+}
+
+func (s *scenario) addActivity(a *mockapi.Activity) {
+	if a.ID == "" {
+		a.ID = "act" + strconv.Itoa(len(s.activities)+1)
+	}
+	if a.Project == "" {
+		a.Project = s.ProjectID
+	}
+	s.activities = append(s.activities, a)
+	if s.APIServer != nil {
+		s.Handler.SetProjectActivities(s.ProjectID, s.activities)
+	}
+}
+
+// Activities returns the project activities added so far, including those
+// added by stand-in handlers (e.g. after a Git push).
+func (s *scenario) Activities() []*mockapi.Activity {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*mockapi.Activity(nil), s.activities...)
+}
+
+// Deployment returns the current deployment of an environment, creating an empty one if necessary.
+func (s *scenario) Deployment(envName string) *mockapi.Deployment {
+	if d, ok := s.deployments[envName]; ok {
+		return d
+	}
+	s.Env(envName)
+	d := &mockapi.Deployment{
+		WebApps:  map[string]mockapi.App{},
+		Services: map[string]mockapi.App{},
+		Workers:  map[string]mockapi.Worker{},
+		Routes:   map[string]any{},
+		Links: mockapi.MakeHALLinks("self=/projects/" + url.PathEscape(s.ProjectID) +
+			"/environments/" + url.PathEscape(envName) + "/deployment/current"),
+	}
+	s.deployments[envName] = d
+	return d
+}
+
+// WithApp adds an app to an environment's deployment.
+func (s *scenario) WithApp(envName string, app mockapi.App) *scenario {
+	s.Deployment(envName).WebApps[app.Name] = app
+	return s
+}
+
+// WithWorker adds a worker to an environment's deployment.
+func (s *scenario) WithWorker(envName string, worker mockapi.Worker) *scenario {
+	s.Deployment(envName).Workers[worker.App.Name] = worker
+	return s
+}
+
+// WithService adds a service to an environment's deployment.
+func (s *scenario) WithService(envName string, service mockapi.App) *scenario {
+	s.Deployment(envName).Services[service.Name] = service
+	return s
+}
+
+// WithRoutes sets the routes of an environment's deployment.
+func (s *scenario) WithRoutes(envName string, routes map[string]any) *scenario {
+	s.Deployment(envName).Routes = routes
+	return s
+}
+
+// Use adds a middleware in front of the mock API handler. All requests are
+// recorded before they reach any middleware. It must be called before Factory.
+func (s *scenario) Use(middleware func(http.Handler) http.Handler) *scenario {
+	if s.APIServer != nil {
+		s.t.Fatal("Middleware must be added before the servers are started")
+	}
+	s.middleware = append(s.middleware, middleware)
+	return s
+}
+
+// WithGitRepository serves the project's repository from a local Git server.
+//
+// A push to a branch creates its environment, if it does not exist, and adds
+// an "environment.push" activity. Push options are applied to new
+// environments as the platform would (environment.status, environment.parent
+// and environment.type). Branching an environment through the API (with the
+// envBranch capability) creates the environment and the branch in the repository.
+func (s *scenario) WithGitRepository() *scenario {
+	s.Git = newGitServer(s.t)
+	s.Project.Repository.URL = s.Git.Init(s.ProjectID)
+	s.Git.OnPush(s.handlePush)
+	return s.Use(standIn(func(r chi.Router) {
+		r.Post("/projects/{project}/environments/{environment}/branch", s.handleBranch)
+	}))
+}
+
+// WithActivitySimulator serves activities from an activitySimulator, which
+// tests can use (via s.Sim) to start activities that progress as they are polled.
+func (s *scenario) WithActivitySimulator() *scenario {
+	s.Sim = newActivitySimulator(nil, s.ProjectID)
+	return s.Use(func(next http.Handler) http.Handler {
+		s.Sim.next = next
+		return s.Sim
+	})
+}
+
+// Clone runs "project:get" to clone the project into a new directory, and
+// returns a command factory which runs commands inside the clone.
+func (s *scenario) Clone() *cmdFactory {
+	f := s.Factory()
+	dir := filepath.Join(s.t.TempDir(), "project")
+	f.Run("project:get", s.ProjectID, dir)
+	return f.InDir(dir)
+}
+
+func (s *scenario) handlePush(push gitPush) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range push.Updates {
+		branch, ok := u.Branch()
+		if !ok || u.Deleted() {
+			continue
+		}
+		if s.findEnv(branch) == nil {
+			var parent any
+			envType, status := "production", "active"
+			if branch != s.Project.DefaultBranch {
+				parent, envType, status = s.Project.DefaultBranch, "development", "inactive"
+			}
+			if p, ok := push.Options["environment.parent"]; ok {
+				parent = p
+			}
+			if typ, ok := push.Options["environment.type"]; ok {
+				envType = typ
+			}
+			if push.Options["environment.status"] == "active" {
+				status = "active"
+			}
+			s.WithEnv(branch, envType, status, parent, envActivities)
+		}
+		s.addActivity(&mockapi.Activity{
+			Type:              "environment.push",
+			State:             "complete",
+			Result:            "success",
+			CompletionPercent: 100,
+			Environments:      []string{branch},
+			Description:       "<user>Mock User</user> pushed to <environment>" + branch + "</environment>",
+			Text:              "Mock User pushed to " + branch,
+			CreatedAt:         time.Now(),
+			UpdatedAt:         time.Now(),
+		})
+	}
+	s.Handler.SetEnvironments(s.envs)
+}
+
+func (s *scenario) handleBranch(w http.ResponseWriter, req *http.Request) {
+	var params struct {
+		Name  string `json:"name"`
+		Title string `json:"title"`
+		Type  string `json:"type"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil || params.Name == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": "Invalid branch parameters"})
+		return
+	}
+	parent := chi.URLParam(req, "environment")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.findEnv(params.Name) != nil {
+		writeJSON(w, http.StatusConflict, map[string]any{"message": "The environment already exists"})
+		return
+	}
+	if s.Git.HasBranch(s.ProjectID, parent) {
+		if err := s.Git.CreateBranch(s.ProjectID, params.Name, parent); err != nil {
+			s.t.Error(err)
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"message": err.Error()})
+			return
+		}
+	}
+	envType := params.Type
+	if envType == "" {
+		envType = "development"
+	}
+	s.WithEnv(params.Name, envType, "active", parent, envActivities)
+	if params.Title != "" {
+		s.Env(params.Name).Title = params.Title
+	}
+	s.addActivity(&mockapi.Activity{
+		Type:              "environment.branch",
+		State:             "complete",
+		Result:            "success",
+		CompletionPercent: 100,
+		Environments:      []string{parent, params.Name},
+		Description:       "<user>Mock User</user> branched <environment>" + params.Name + "</environment> from parent <environment>" + parent + "</environment>",
+		Text:              "Mock User branched " + params.Name + " from parent " + parent,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	})
+	s.Handler.SetEnvironments(s.envs)
+
+	// The activity is already complete, so none is returned to wait for.
+	writeJSON(w, http.StatusAccepted, map[string]any{"_embedded": map[string]any{"activities": []any{}}})
+}
+
+// Apply sends the fixtures to the mock API handler. It is called by Factory,
+// and can be called again after fixtures are changed.
+func (s *scenario) Apply() {
+	var teams []*teamFixture
+	if s.teams != nil {
+		s.teams.mu.Lock()
+		defer s.teams.mu.Unlock()
+		teams = s.teams.teams
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Handler.SetMyUser(&mockapi.User{ID: s.MyUserID})
+	s.applyOrgLinks()
+	s.applyProjectLinks()
+	s.applyEnvLinks()
+	if len(s.Orgs) > 0 {
+		s.Handler.SetOrgs(s.Orgs)
+	}
+	if grants := s.userGrants(teams); len(grants) > 0 {
+		s.Handler.SetUserGrants(grants)
+	}
+	s.Handler.SetProjects([]*mockapi.Project{s.Project})
+	for name, d := range s.deployments {
+		s.Env(name).SetCurrentDeployment(d)
+	}
+	s.Handler.SetEnvironments(s.envs)
+	if len(s.activities) > 0 {
+		s.Handler.SetProjectActivities(s.ProjectID, s.activities)
+	}
+}
+
+// applyEnvLinks sets the links of environments which depend on stand-ins. The
+// scenario's lock must be held.
+func (s *scenario) applyEnvLinks() {
+	if s.lifecycle != nil {
+		s.lifecycle.applyLinks()
+	}
+	if s.envSync != nil {
+		s.envSync.applyLinks()
+	}
+}
+
+// Factory starts the servers (once) and returns a command factory using them.
+func (s *scenario) Factory() *cmdFactory {
+	if s.factory != nil {
+		return s.factory
+	}
+	s.Apply()
+
+	s.AuthServer = mockapi.NewAuthServer(s.t)
+	s.t.Cleanup(s.AuthServer.Close)
+
+	var handler http.Handler = s.Handler
+	for i := len(s.middleware) - 1; i >= 0; i-- {
+		handler = s.middleware[i](handler)
+	}
+	s.Recorder = newRequestRecorder(handler)
+	s.APIServer = httptest.NewServer(s.Recorder)
+	s.t.Cleanup(s.APIServer.Close)
+
+	s.factory = newCommandFactory(s.t, s.APIServer.URL, s.AuthServer.URL)
+	if s.Git != nil {
+		s.factory.extraEnv = append(s.factory.extraEnv, gitEnv()...)
+	}
+	if s.sizingAPIEnabled() {
+		s.factory.extraEnv = append(s.factory.extraEnv, EnvPrefix+"API_SIZING=1")
+	}
+	if len(s.sshHandlers) > 0 {
+		s.startSSHServer()
+	}
+	return s.factory
+}
+
+// standIn returns a middleware which serves the given routes, for API
+// endpoints that the mock API handler lacks. Other requests are passed on.
+func standIn(routes func(r chi.Router)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		r := chi.NewRouter()
+		routes(r)
+		r.NotFound(next.ServeHTTP)
+		r.MethodNotAllowed(next.ServeHTTP)
+		return r
+	}
+}
+
+// capabilitiesAPI serves the project's capabilities, from the features that
+// stand-ins enable.
+type capabilitiesAPI struct {
+	mu                   sync.Mutex
+	nonProductionDomains bool
+	integrationTypes     []string
+}
+
+// serveCapabilities serves the project's capabilities (once).
+func (s *scenario) serveCapabilities() *capabilitiesAPI {
+	if s.capabilities == nil {
+		s.capabilities = &capabilitiesAPI{}
+		s.Use(standIn(func(r chi.Router) {
+			r.Get("/projects/{project}/capabilities", s.capabilities.handleGet)
+		}))
+	}
+	return s.capabilities
+}
+
+func (api *capabilitiesAPI) enableNonProductionDomains() {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.nonProductionDomains = true
+}
+
+func (api *capabilitiesAPI) setIntegrationTypes(types []string) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.integrationTypes = types
+}
+
+func (api *capabilitiesAPI) handleGet(w http.ResponseWriter, _ *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	integrations := map[string]any{"enabled": len(api.integrationTypes) > 0}
+	if len(api.integrationTypes) > 0 {
+		config := map[string]any{}
+		for _, t := range api.integrationTypes {
+			config[t] = map[string]any{"enabled": true}
+		}
+		integrations["config"] = config
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"custom_domains": map[string]any{"enabled": api.nonProductionDomains},
+		"integrations":   integrations,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func makeEnv(projectID, name, envType, status string, parent any) *mockapi.Environment {
+	created, _ := time.Parse(time.RFC3339, "2014-04-01T10:00:00Z")
+	updated, _ := time.Parse(time.RFC3339, "2014-04-01T11:00:00Z")
+
+	return &mockapi.Environment{
+		ID:          name,
+		Name:        name,
+		MachineName: name + "-xyz",
+		Title:       strings.ToTitle(name[:1]) + name[1:],
+		Parent:      parent,
+		Type:        envType,
+		Status:      status,
+		Project:     projectID,
+		CreatedAt:   created,
+		UpdatedAt:   updated,
+		Links: mockapi.MakeHALLinks(
+			"self=/projects/"+url.PathEscape(projectID)+"/environments/"+url.PathEscape(name),
+			"#edit=/projects/"+url.PathEscape(projectID)+"/environments/"+url.PathEscape(name),
+		),
+	}
+}
+
+func makeOrg(id, name, label, owner, typ string) *mockapi.Org {
+	return &mockapi.Org{
+		ID:           id,
+		Type:         typ,
+		Name:         name,
+		Label:        label,
+		Owner:        owner,
+		Capabilities: []string{},
+		Links:        mockapi.MakeHALLinks("self=/organizations/" + url.PathEscape(id)),
+	}
+}
+
+func makeProject(id, org, vendor, title, region string) *mockapi.Project {
+	return &mockapi.Project{
+		ID:           id,
+		Organization: org,
+		Vendor:       vendor,
+		Title:        title,
+		Region:       region,
+		Links:        mockapi.MakeHALLinks("self=/projects/" + url.PathEscape(id)),
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/platformsh/cli/pkg/mockssh"
+)
+
+// mysqlCommandPattern matches the MySQL client, as the CLI invokes it
+// directly or via a fallback from the MariaDB client.
+var mysqlCommandPattern = regexp.MustCompile(`^(?:mysql|mariadb) |^cmd="\$\(command -v mariadb \|\| echo -n mysql\)"; "\$cmd" `)
+
+type sqlResultRule struct {
+	pattern *regexp.Regexp
+	result  commandResult
+}
+
+// sqlInvocation is a run of a database client.
+type sqlInvocation struct {
+	// Client is "mysql" or "psql".
+	Client string
+	// Command is the full command line.
+	Command string
+	// Query is the query from the command line, or else from stdin.
+	Query string
+}
+
+// sqlClient emulates the MySQL (or MariaDB) and PostgreSQL command-line
+// clients over SSH. It records each run, and answers queries with results
+// set by On. Other queries succeed with no output.
+type sqlClient struct {
+	mu          sync.Mutex
+	rules       []sqlResultRule
+	invocations []sqlInvocation
+}
+
+// WithSQLClients emulates the database clients (see sqlClient) on the apps
+// served by WithSSH. Tests can use s.SQL to set query results and check runs.
+func (s *scenario) WithSQLClients() *scenario {
+	s.SQL = &sqlClient{}
+	s.handleSSHCommands(func(command string) bool {
+		return sqlClientName(command) != ""
+	}, s.SQL.handle)
+	return s
+}
+
+// sqlClientName returns the name of the client a command runs, if any.
+func sqlClientName(command string) string {
+	switch {
+	case mysqlCommandPattern.MatchString(command):
+		return "mysql"
+	case strings.HasPrefix(command, "psql "):
+		return "psql"
+	}
+	return ""
+}
+
+// On sets the result of queries matching a pattern (a regular expression).
+// Later calls take precedence, so tests can change results between commands.
+func (c *sqlClient) On(pattern string, result commandResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rules = append(c.rules, sqlResultRule{pattern: regexp.MustCompile(pattern), result: result})
+}
+
+// Invocations returns the runs of the clients so far.
+func (c *sqlClient) Invocations() []sqlInvocation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.invocations)
+}
+
+// Reset forgets the runs so far (but not the results).
+func (c *sqlClient) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invocations = nil
+}
+
+func (c *sqlClient) handle(_ ssh.ConnMetadata, command string, cio mockssh.CommandIO) int {
+	client := sqlClientName(command)
+	flag := " --execute "
+	if client == "psql" {
+		flag = " -c "
+	}
+	var query string
+	if i := strings.LastIndex(command, flag); i != -1 {
+		// The query is the flag's argument, a single quoted word.
+		if words := shellSplit(command[i+len(flag):]); len(words) > 0 {
+			query = words[0]
+		}
+	} else {
+		b, _ := io.ReadAll(cio.StdIn)
+		query = string(b)
+	}
+
+	c.mu.Lock()
+	c.invocations = append(c.invocations, sqlInvocation{Client: client, Command: command, Query: query})
+	var result commandResult
+	for _, r := range slices.Backward(c.rules) {
+		if r.pattern.MatchString(query) {
+			result = r.result
+			break
+		}
+	}
+	c.mu.Unlock()
+
+	_, _ = io.WriteString(cio.StdOut, result.Output)
+	_, _ = io.WriteString(cio.StdErr, result.Error)
+	return result.ExitCode
+}
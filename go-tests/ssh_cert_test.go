@@ -10,6 +10,7 @@ import (
 )
 
 func TestSSHCerts(t *testing.T) {
+	t.Parallel()
 	authServer := mockapi.NewAuthServer(t)
 	defer authServer.Close()
 
@@ -13,6 +13,7 @@ import (
 )
 
 func TestSSH(t *testing.T) {
+	t.Parallel()
 	authServer := mockapi.NewAuthServer(t)
 	defer authServer.Close()
 
@@ -67,7 +68,6 @@ func TestSSH(t *testing.T) {
 		EnvPrefix + "SSH_HOST_KEYS=" + sshServer.HostKeyConfig(),
 	}
 
-	f.Run("cc")
 	wd, _ := os.Getwd()
 	assert.Equal(t, wd+"\n", f.Run("ssh", "-p", projectID, "-e", ".", "pwd"))
 
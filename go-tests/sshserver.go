@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/platformsh/cli/pkg/mockapi"
+	"github.com/platformsh/cli/pkg/mockssh"
+)
+
+// WithSSH serves SSH for an app on an environment from a mock SSH server,
+// adding the app to the environment's deployment if necessary. Commands are
+// run locally (see mockssh.ExecHandler), with the given environment
+// variables, e.g. from relationshipsFixture.EnvVar.
+//
+// Each app has its own SSH user, so apps can have different variables.
+func (s *scenario) WithSSH(envName, appName string, env ...string) *scenario {
+	if _, ok := s.Deployment(envName).WebApps[appName]; !ok {
+		s.WithApp(envName, mockapi.App{Name: appName, Type: "golang:1.23", Size: "M", Disk: 2048, Mounts: map[string]mockapi.Mount{}})
+	}
+	user := s.ProjectID + "-" + envName + "--" + appName
+	s.Env(envName).Links["pf:ssh:"+appName+":0"] = mockapi.HALLink{HREF: "ssh://" + url.PathEscape(user) + "@ssh.cli-tests.example.com"}
+	if s.sshHandlers == nil {
+		s.sshHandlers = make(map[string]mockssh.CommandHandler)
+	}
+	s.sshHandlers[user] = mockssh.ExecHandler(s.t.TempDir(), env)
+	return s
+}
+
+// commandResult is the result of a program emulated over SSH.
+type commandResult struct {
+	Output   string
+	Error    string
+	ExitCode int
+}
+
+// sshCommandHandler handles the commands run over SSH which it matches, in
+// front of the apps' default handlers.
+type sshCommandHandler struct {
+	match   func(command string) bool
+	handler mockssh.CommandHandler
+}
+
+// handleSSHCommands adds a handler for the commands run over SSH (on any app)
+// which match a function, e.g. to emulate programs.
+func (s *scenario) handleSSHCommands(match func(command string) bool, handler mockssh.CommandHandler) {
+	s.sshCommands = append(s.sshCommands, sshCommandHandler{match: match, handler: handler})
+}
+
+// startSSHServer starts the mock SSH server, which trusts certificates from
+// the auth server, and configures the command factory to connect to it.
+func (s *scenario) startSSHServer() {
+	server, err := mockssh.NewServer(s.t, s.AuthServer.URL+"/ssh/authority")
+	require.NoError(s.t, err)
+	server.CommandHandler = func(conn ssh.ConnMetadata, command string, cio mockssh.CommandIO) int {
+		for _, h := range s.sshCommands {
+			if h.match(command) {
+				return h.handler(conn, command, cio)
+			}
+		}
+		handler, ok := s.sshHandlers[conn.User()]
+		if !ok {
+			_, _ = fmt.Fprintln(cio.StdErr, "Unknown SSH user: "+conn.User())
+			return 1
+		}
+		return handler(conn, command, cio)
+	}
+	s.t.Cleanup(func() {
+		if err := server.Stop(); err != nil {
+			s.t.Error(err)
+		}
+	})
+	s.SSH = server
+	s.factory.extraEnv = append(s.factory.extraEnv,
+		EnvPrefix+"SSH_OPTIONS=HostName 127.0.0.1\nPort "+strconv.Itoa(server.Port()),
+		EnvPrefix+"SSH_HOST_KEYS="+server.HostKeyConfig(),
+	)
+}
+
+// shellSplit splits a command line into words, removing the quotes and
+// escapes which the CLI adds (see OsUtil::escapePosixShellArg). Other shell
+// syntax is not interpreted.
+func shellSplit(line string) []string {
+	var (
+		words  []string
+		b      strings.Builder
+		inWord bool
+	)
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; c {
+		case ' ', '\t', '\n':
+			if inWord {
+				words = append(words, b.String())
+				b.Reset()
+				inWord = false
+			}
+		case '\'':
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end == -1 {
+				end = len(line) - i - 1
+			}
+			b.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case '\\':
+			if i+1 < len(line) {
+				i++
+				b.WriteByte(line[i])
+			}
+			inWord = true
+		default:
+			b.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, b.String())
+	}
+	return words
+}
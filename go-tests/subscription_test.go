@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupSubscriptionTest creates a scenario with an organization (acme)
+// containing subscriptions in various states, including one for the
+// scenario's project, and an organization (hooli) without subscriptions.
+func setupSubscriptionTest(t *testing.T) *scenario {
+	s := newScenario(t).WithOrg("org-id-1", "acme", "ACME Inc.")
+	s.Orgs = append(s.Orgs, makeOrg("org-id-2", "hooli", "Hooli", s.MyUserID, "flexible"))
+	return s.WithSubscriptions(
+		&subscriptionFixture{ProjectID: s.ProjectID, Storage: 10240},
+		&subscriptionFixture{ProjectID: "project-2", ProjectTitle: "Project 2", ProjectRegion: "region-2", Plan: "standard"},
+		&subscriptionFixture{ProjectID: "project-3", ProjectTitle: "Suspended", ProjectRegion: "region-1", Status: "suspended"},
+		&subscriptionFixture{ProjectID: "project-4", ProjectTitle: "Deleted", ProjectRegion: "region-1", Status: "deleted"},
+		&subscriptionFixture{ProjectID: "project-5", ProjectTitle: "Provisioning", ProjectRegion: "region-2", Status: "provisioning"},
+	)
+}
+
+func TestOrgSubscriptionList(t *testing.T) {
+	t.Parallel()
+	s := setupSubscriptionTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	// Only active and suspended subscriptions are listed.
+	assertTrimmed(t, `
+Subscription ID	Project ID	Title	Region
+1	`+p+`	Project 1	region-1
+2	project-2	Project 2	region-2
+3	project-3	Suspended	region-1
+`, f.Run("org:subs", "-o", "acme", "--format", "plain"))
+
+	stdOut, stdErr, err := f.RunCombinedOutput("org:subscription:list", "-o", "acme", "--count", "2", "--columns", "id,project_title")
+	require.NoError(t, err)
+	assertTrimmed(t, `
++-----------------+-----------+
+| Subscription ID | Title     |
++-----------------+-----------+
+| 1               | Project 1 |
+| 2               | Project 2 |
++-----------------+-----------+
+`, stdOut)
+	assert.Contains(t, stdErr, "Subscriptions belonging to the organization ACME Inc. (acme) (page 1)")
+	assert.Contains(t, stdErr, "More subscriptions are available on the next page (--page 2)")
+	assert.Equal(t, "2", s.Recorder.Find("GET", "/organizations/org-id-1/subscriptions")[0].Query.Get("range"))
+
+	stdOut, stdErr, err = f.RunCombinedOutput("org:subscription:list", "-o", "acme", "--count", "2", "--page", "2", "--columns", "id,project_title")
+	require.NoError(t, err)
+	assert.Contains(t, stdOut, "| 3               | Suspended |")
+	assert.NotContains(t, stdOut, "Project 1")
+	assert.Contains(t, stdErr, "(page 2)")
+	assert.NotContains(t, stdErr, "More subscriptions are available")
+
+	// Pagination can be disabled, which fetches all pages.
+	assert.Equal(t, "ID\n1\n2\n3\n", f.Run("org:subs", "-o", "acme", "--count", "0", "--format", "plain", "--columns", "id"))
+
+	_, stdErr, err = f.RunCombinedOutput("org:subs", "-o", "acme", "--count", "2", "--page", "3")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "No subscriptions were found on this page.")
+
+	_, stdErr, err = f.RunCombinedOutput("org:subs", "-o", "acme", "--count", "51")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The --count must be a number between 1 and 50, or 0 to disable pagination.")
+
+	_, stdErr, err = f.RunCombinedOutput("org:subs", "-o", "hooli")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "No subscriptions were found belonging to the organization Hooli (hooli).")
+}
+
+func TestSubscriptionInfo(t *testing.T) {
+	t.Parallel()
+	s := setupSubscriptionTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	assertTrimmed(t, "development", f.Run("subscription:info", "-p", p, "plan"))
+	assertTrimmed(t, "10240", f.Run("subscription:info", "-p", p, "storage"))
+	assertTrimmed(t, "Project 1", f.Run("subscription:info", "-p", p, "project_title"))
+	assertTrimmed(t, `
+id	plan	environments	storage	status
+1	development	3	10240	active
+`, f.Run("subscription:info", "-p", p, "--format", "plain", "--columns", "id,plan,environments,storage,status"))
+
+	// Changes are confirmed with a warning about the cost.
+	_, stdErr, err := f.RunCombinedOutput("subscription:info", "-p", p, "storage", "20480")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "This action may increase the cost of your subscription.")
+	assert.Contains(t, stdErr, "Are you sure you want to change property 'storage' from 10240 to 20480?")
+	assert.Contains(t, stdErr, "Property storage set to: 20480")
+	s.Recorder.AssertOneJSON(t, "PATCH", "/organizations/org-id-1/subscriptions/1", `{"storage": 20480}`)
+	assert.Equal(t, 20480, s.Subscription("1").Storage)
+
+	_, stdErr, err = f.RunCombinedOutput("subscription:info", "-p", p, "plan", "standard")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "This action may change the cost of your subscription.")
+	assert.Contains(t, stdErr, "Property plan set to: standard")
+	assertTrimmed(t, "standard", f.Run("subscription:info", "-p", p, "plan"))
+
+	s.Recorder.Reset()
+	_, stdErr, err = f.RunCombinedOutput("subscription:info", "-p", p, "environments", "3")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "Property environments already set as: 3")
+
+	_, stdErr, err = f.RunCombinedOutput("subscription:info", "-p", p, "status", "suspended")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Property not writable: status")
+	s.Recorder.AssertNone(t, "PATCH", "/subscriptions/1")
+
+	// The CLI sends the storage as an integer, and shows the API's error.
+	_, stdErr, err = f.RunCombinedOutput("subscription:info", "-p", p, "storage", "1500")
+	assertExitCode(t, 255, err)
+	assert.Contains(t, stdErr, "Storage must be a multiple of 1024 MiB.")
+	s.Recorder.AssertOneJSON(t, "PATCH", "/organizations/org-id-1/subscriptions/1", `{"storage": 1500}`)
+	assert.Equal(t, 20480, s.Subscription("1").Storage)
+
+	_, stdErr, err = f.RunCombinedOutput("subscription:info", "--id", "99")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Subscription not found: 99")
+}
+
+func TestSubscriptionEstimate(t *testing.T) {
+	t.Parallel()
+	s := setupSubscriptionTest(t)
+	f := s.Factory()
+
+	// Declining the cost confirmation does not create a subscription.
+	_, stdErr, err := f.RunCombinedOutput("project:create", "--org", "acme", "--title", "Estimate", "--region", "test-region",
+		"--environments", "5", "--storage", "10", "--no")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The estimated monthly cost of this project is: $30 USD")
+	estimate := s.Recorder.RequireOne(t, "GET", "/organizations/org-id-1/subscriptions/estimate")
+	assert.Equal(t, "5", estimate.Query.Get("environments"))
+	assert.Equal(t, "10240", estimate.Query.Get("storage"))
+	s.Recorder.AssertNone(t, "POST", "/organizations/org-id-1/subscriptions")
+}
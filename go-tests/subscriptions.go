@@ -0,0 +1,349 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// planPrices are the monthly prices of subscription plans in US dollars,
+// including 3 environments and 5 GiB of storage.
+var planPrices = map[string]int{"development": 10, "standard": 50, "medium": 100, "large": 200}
+
+const (
+	// environmentPrice is the monthly price of each extra environment.
+	environmentPrice = 5
+	// storagePrice is the monthly price of each extra GiB of storage.
+	storagePrice = 2
+)
+
+// subscriptionFixture is an organization's subscription, served by the
+// subscriptions stand-in.
+type subscriptionFixture struct {
+	// ID defaults to a number.
+	ID string
+	// OrgID defaults to the project's organization.
+	OrgID     string
+	ProjectID string
+	// ProjectTitle and ProjectRegion default to those of the scenario's
+	// project, if ProjectID is the same.
+	ProjectTitle  string
+	ProjectRegion string
+	// Plan defaults to "development".
+	Plan string
+	// Environments defaults to 3.
+	Environments int
+	// Storage is in MiB, and defaults to 5120.
+	Storage int
+	// Status is "active" (the default), "suspended", "provisioning" or "deleted".
+	Status    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// subscriptionsAPI stands in for the organization subscriptions API.
+type subscriptionsAPI struct {
+	s *scenario
+
+	mu            sync.Mutex
+	subscriptions []*subscriptionFixture
+}
+
+// WithSubscriptions serves subscriptions from a subscriptionsAPI, and adds
+// subscriptions.
+//
+// A subscription for the scenario's project is linked from the project, so
+// that it can be found with subscription:info. Subscription estimates are
+// calculated from planPrices. Other requests, e.g. to create a subscription,
+// are passed on to the mock API.
+func (s *scenario) WithSubscriptions(subscriptions ...*subscriptionFixture) *scenario {
+	if s.subscriptions == nil {
+		s.subscriptions = &subscriptionsAPI{s: s}
+		s.Use(s.subscriptions.middleware)
+	}
+	api := s.subscriptions
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	for _, sub := range subscriptions {
+		if sub.ID == "" {
+			sub.ID = strconv.Itoa(len(api.subscriptions) + 1)
+		}
+		if sub.OrgID == "" {
+			sub.OrgID = s.Project.Organization
+		}
+		if sub.ProjectID == s.ProjectID && sub.ProjectTitle == "" {
+			sub.ProjectTitle, sub.ProjectRegion = s.Project.Title, s.Project.Region
+		}
+		if sub.Plan == "" {
+			sub.Plan = "development"
+		}
+		if sub.Environments == 0 {
+			sub.Environments = 3
+		}
+		if sub.Storage == 0 {
+			sub.Storage = 5120
+		}
+		if sub.Status == "" {
+			sub.Status = "active"
+		}
+		if sub.CreatedAt.IsZero() {
+			sub.CreatedAt, _ = time.Parse(time.RFC3339, "2014-04-01T10:00:00Z")
+		}
+		if sub.UpdatedAt.IsZero() {
+			sub.UpdatedAt = sub.CreatedAt
+		}
+		api.subscriptions = append(api.subscriptions, sub)
+	}
+	return s
+}
+
+// Subscription returns a subscription by ID, or nil if it does not exist.
+func (s *scenario) Subscription(id string) *subscriptionFixture {
+	s.subscriptions.mu.Lock()
+	defer s.subscriptions.mu.Unlock()
+	return s.subscriptions.find("", id)
+}
+
+func (api *subscriptionsAPI) middleware(next http.Handler) http.Handler {
+	return standIn(func(r chi.Router) {
+		r.Get("/projects/{project}", func(w http.ResponseWriter, req *http.Request) {
+			api.handleGetProject(w, req, next)
+		})
+		r.Get("/organizations/{organization}/subscriptions", api.handleList)
+		r.Get("/organizations/{organization}/subscriptions/estimate", api.handleEstimate)
+		r.Get("/organizations/{organization}/subscriptions/{id}", func(w http.ResponseWriter, req *http.Request) {
+			api.handleGet(w, req, next)
+		})
+		r.Patch("/organizations/{organization}/subscriptions/{id}", func(w http.ResponseWriter, req *http.Request) {
+			api.handleUpdate(w, req, next)
+		})
+	})(next)
+}
+
+// find finds a subscription by ID, optionally in an organization.
+func (api *subscriptionsAPI) find(orgID, id string) *subscriptionFixture {
+	for _, sub := range api.subscriptions {
+		if sub.ID == id && (orgID == "" || sub.OrgID == orgID) {
+			return sub
+		}
+	}
+	return nil
+}
+
+// handleGetProject adds subscription information to a project from the mock
+// API.
+func (api *subscriptionsAPI) handleGetProject(w http.ResponseWriter, req *http.Request, next http.Handler) {
+	rec := httptest.NewRecorder()
+	next.ServeHTTP(rec, req)
+	body := map[string]any{}
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &body) != nil {
+		copyResponse(w, rec)
+		return
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	i := slices.IndexFunc(api.subscriptions, func(sub *subscriptionFixture) bool {
+		return sub.ProjectID == chi.URLParam(req, "project")
+	})
+	if i == -1 {
+		copyResponse(w, rec)
+		return
+	}
+	sub := api.subscriptions[i]
+	body["subscription"] = map[string]any{
+		"license_uri":    subscriptionPath(sub),
+		"plan":           sub.Plan,
+		"environments":   sub.Environments,
+		"storage":        sub.Storage,
+		"included_users": 1,
+		"user_licenses":  1,
+		"restricted":     false,
+		"suspended":      sub.Status == "suspended",
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// handleList lists an organization's subscriptions, filtered by
+// status (exactly, or with the IN operator). Pages are selected by the "page"
+// (from 1) and "range" (the page size) query parameters.
+func (api *subscriptionsAPI) handleList(w http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+	var statuses []string
+	for k, v := range q {
+		if k == "filter[status]" || strings.HasPrefix(k, "filter[status][value]") {
+			statuses = append(statuses, v...)
+		}
+	}
+	orgID := chi.URLParam(req, "organization")
+	if !api.s.hasOrg(orgID) {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Organization not found"})
+		return
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	items := []any{}
+	for _, sub := range api.subscriptions {
+		if sub.OrgID == orgID && (statuses == nil || slices.Contains(statuses, sub.Status)) {
+			items = append(items, subscriptionData(sub))
+		}
+	}
+
+	total := len(items)
+	size, err := strconv.Atoi(q.Get("range"))
+	if err != nil || size <= 0 {
+		size = total
+	}
+	page, err := strconv.Atoi(q.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	start := min((page-1)*size, total)
+	end := min(start+size, total)
+	links := map[string]any{
+		"self": map[string]any{"href": req.URL.String()},
+	}
+	if end < total {
+		q.Set("page", strconv.Itoa(page+1))
+		next := url.URL{Path: req.URL.Path, RawQuery: q.Encode()}
+		links["next"] = map[string]any{"href": next.String()}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items":  items[start:end],
+		"count":  total,
+		"_links": links,
+	})
+}
+
+// handleGet serves a subscription, or passes the request on to
+// the mock API if it is not a fixture (e.g. a new subscription).
+func (api *subscriptionsAPI) handleGet(w http.ResponseWriter, req *http.Request, next http.Handler) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	sub := api.find(chi.URLParam(req, "organization"), chi.URLParam(req, "id"))
+	if sub == nil {
+		next.ServeHTTP(w, req)
+		return
+	}
+	writeJSON(w, http.StatusOK, subscriptionData(sub))
+}
+
+// handleUpdate changes the plan, environments or storage of a
+// subscription. Storage must be a multiple of 1024 MiB.
+func (api *subscriptionsAPI) handleUpdate(w http.ResponseWriter, req *http.Request, next http.Handler) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	sub := api.find(chi.URLParam(req, "organization"), chi.URLParam(req, "id"))
+	if sub == nil {
+		next.ServeHTTP(w, req)
+		return
+	}
+	var params struct {
+		Plan         *string `json:"plan"`
+		Environments *int    `json:"environments"`
+		Storage      *int    `json:"storage"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"detail": "Invalid subscription parameters"})
+		return
+	}
+	if sub.Status != "active" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"detail": "Only active subscriptions can be updated."})
+		return
+	}
+	if params.Plan != nil {
+		if _, ok := planPrices[*params.Plan]; !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"detail": "Invalid plan: " + *params.Plan})
+			return
+		}
+	}
+	if params.Environments != nil && *params.Environments < 1 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"detail": "At least one environment is required."})
+		return
+	}
+	if params.Storage != nil && (*params.Storage < 1024 || *params.Storage%1024 != 0) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"detail": "Storage must be a multiple of 1024 MiB."})
+		return
+	}
+	if params.Plan != nil {
+		sub.Plan = *params.Plan
+	}
+	if params.Environments != nil {
+		sub.Environments = *params.Environments
+	}
+	if params.Storage != nil {
+		sub.Storage = *params.Storage
+	}
+	sub.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	writeJSON(w, http.StatusOK, subscriptionData(sub))
+}
+
+// handleEstimate estimates the monthly cost of a subscription.
+// The mock API may offer plans which are not in planPrices: these cost the
+// same as the development plan.
+func (api *subscriptionsAPI) handleEstimate(w http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+	price, ok := planPrices[q.Get("plan")]
+	if !ok {
+		price = planPrices["development"]
+	}
+	environments, _ := strconv.Atoi(q.Get("environments"))
+	storage, _ := strconv.Atoi(q.Get("storage"))
+	extraEnvironments := max(environments-3, 0) * environmentPrice
+	extraStorage := max(storage/1024-5, 0) * storagePrice
+	writeJSON(w, http.StatusOK, map[string]any{
+		"plan":          formatPrice(price),
+		"environments":  formatPrice(extraEnvironments),
+		"storage":       formatPrice(extraStorage),
+		"user_licenses": formatPrice(0),
+		"total":         formatPrice(price + extraEnvironments + extraStorage),
+	})
+}
+
+// formatPrice formats a price in US dollars, like the API's estimates.
+func formatPrice(dollars int) string {
+	digits := strconv.Itoa(dollars)
+	var b strings.Builder
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return fmt.Sprintf("$%s USD", b.String())
+}
+
+func subscriptionPath(sub *subscriptionFixture) string {
+	return "/organizations/" + url.PathEscape(sub.OrgID) + "/subscriptions/" + url.PathEscape(sub.ID)
+}
+
+// subscriptionData returns the API representation of a subscription.
+func subscriptionData(sub *subscriptionFixture) map[string]any {
+	self := subscriptionPath(sub)
+	return map[string]any{
+		"id":              sub.ID,
+		"organization_id": sub.OrgID,
+		"status":          sub.Status,
+		"plan":            sub.Plan,
+		"environments":    sub.Environments,
+		"storage":         sub.Storage,
+		"user_licenses":   1,
+		"project_id":      sub.ProjectID,
+		"project_title":   sub.ProjectTitle,
+		"project_region":  sub.ProjectRegion,
+		"created_at":      sub.CreatedAt.Format(time.RFC3339),
+		"updated_at":      sub.UpdatedAt.Format(time.RFC3339),
+		"_links": map[string]any{
+			"self":  map[string]any{"href": self},
+			"#edit": map[string]any{"href": self},
+		},
+	}
+}
@@ -0,0 +1,234 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTeamTest creates a scenario with an organization (acme) administered
+// by the current user, two other members, and a team containing one of them.
+func setupTeamTest(t *testing.T) *scenario {
+	return newScenario(t).
+		WithOrg("org-id-1", "acme", "ACME Inc.").
+		WithOrgMember("org-id-1", "user-id-2").
+		WithOrgMember("org-id-1", "user-id-3").
+		WithTeams(&teamFixture{
+			ID:                 "team1",
+			Label:              "Developers",
+			ProjectPermissions: []string{"viewer", "development:contributor"},
+			Members:            []string{"user-id-2"},
+		})
+}
+
+func TestTeamCreate(t *testing.T) {
+	t.Parallel()
+	s := setupTeamTest(t)
+	f := s.Factory()
+
+	stdOut, stdErr, err := f.RunCombinedOutput("team:create", "-o", "acme", "--label", "Admins", "--role", "admin", "--output-id")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Created team Admins (team2) in the organization ACME Inc. (acme)")
+	assert.Equal(t, "team2\n", stdOut)
+	body := s.Recorder.RequireOne(t, "POST", "/teams").JSON(t)
+	assert.Equal(t, "org-id-1", body["organization_id"])
+	assert.Equal(t, "Admins", body["label"])
+	assert.Equal(t, []any{"admin"}, body["project_permissions"])
+
+	// Environment type roles can be matched by wildcard, and the new team is displayed.
+	s.Recorder.Reset()
+	_, stdErr, err = f.RunCombinedOutput("team:create", "-o", "acme", "--label", "Testers", "--role", "viewer", "--role", "st*:viewer,prod*:contributor")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Created team Testers (team3)")
+	assert.Contains(t, stdErr, "Viewing the team Testers (team3) in the organization ACME Inc. (acme)")
+	assert.ElementsMatch(t, []any{"viewer", "staging:viewer", "production:contributor"},
+		s.Recorder.RequireOne(t, "POST", "/teams").JSON(t)["project_permissions"])
+	assertTrimmed(t, "Testers", f.Run("team:get", "-t", "team3", "-P", "label"))
+
+	_, stdErr, err = f.RunCombinedOutput("team:create", "-o", "acme", "--label", "Viewers", "--role", "viewer")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "At least one environment type role must be specified when the project role is viewer.")
+
+	// Labels are unique in the organization, which the CLI checks first.
+	s.Recorder.Reset()
+	_, stdErr, err = f.RunCombinedOutput("team:create", "-o", "acme", "--label", "developers", "--role", "admin")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Another team team1 exists in the organization with the same label: developers")
+	s.Recorder.AssertNone(t, "POST", "/teams")
+
+	_, stdErr, err = f.RunCombinedOutput("team:create", "-o", "acme", "--label", "developers", "--role", "admin", "--no-check-unique")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "A team already exists with the same label: developers")
+	s.Recorder.AssertCount(t, 1, "POST", "/teams")
+}
+
+func TestTeamList(t *testing.T) {
+	t.Parallel()
+	s := setupTeamTest(t).WithTeams(&teamFixture{
+		ID:                 "team2",
+		Label:              "Admins",
+		ProjectPermissions: []string{"admin"},
+	})
+	f := s.Factory()
+
+	// Teams are sorted by label.
+	assertTrimmed(t, `
++-------+------------+---------+------------+
+| ID    | Label      | # Users | # Projects |
++-------+------------+---------+------------+
+| team2 | Admins     | 0       | 0          |
+| team1 | Developers | 1       | 0          |
++-------+------------+---------+------------+
+`, f.Run("teams", "-o", "acme", "--columns", "id,label,member_count,project_count"))
+
+	assertTrimmed(t, `
+ID	Label	Permissions
+team1	Developers	["viewer","development:contributor"]
+team2	Admins	["admin"]
+`, f.Run("teams", "-o", "acme", "--sort", "label", "--reverse", "--format", "plain", "--columns", "id,label,project_permissions"))
+
+	_, stdErr, err := f.RunCombinedOutput("team:get", "-o", "acme")
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "A --team is required (in non-interactive mode)")
+
+	_, stdErr, err = f.RunCombinedOutput("team:get", "-t", "team9")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Team not found: team9")
+}
+
+func TestTeamProjectsAndUsers(t *testing.T) {
+	t.Parallel()
+	s := setupTeamTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	_, stdErr, err := f.RunCombinedOutput("team:project:add", "-t", "team1", p)
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "The project(s) were successfully added to the team Developers (team1).")
+	s.Recorder.AssertOneJSON(t, "POST", "/teams/team1/project-access", `[{"project_id": "`+p+`"}]`)
+
+	_, stdErr, err = f.RunCombinedOutput("team:project:add", "-t", "team1", p)
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "The team already has access to the project")
+	assert.Contains(t, stdErr, "There are no projects to add.")
+	s.Recorder.AssertCount(t, 1, "POST", "/teams/team1/project-access")
+
+	assertTrimmed(t, `
+Project ID	Project title
+`+p+`	Project 1
+`, f.Run("team:projects", "-t", "team1", "--format", "plain", "--columns", "id,title"))
+
+	// Users are added by email address or ID, if they are in the organization.
+	_, stdErr, err = f.RunCombinedOutput("team:user:add", "-t", "team1", "user-id-3@example.com")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "The user was successfully added to the team Developers (team1).")
+	s.Recorder.AssertOneJSON(t, "POST", "/teams/team1/members", `{"user_id": "user-id-3"}`)
+
+	_, stdErr, err = f.RunCombinedOutput("team:user:add", "-t", "team1", "user-id-2")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "is already in the team Developers (team1).")
+
+	_, stdErr, err = f.RunCombinedOutput("team:user:add", "-t", "team1", "stranger@example.com")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The user with email address stranger@example.com was not found in the organization ACME Inc. (acme).")
+	assert.Contains(t, stdErr, "A team may only contain users who are part of the organization.")
+	s.Recorder.AssertCount(t, 1, "POST", "/teams/team1/members")
+
+	assertTrimmed(t, `
++-----------+-----------------------+
+| User ID   | Email address         |
++-----------+-----------------------+
+| user-id-2 | user-id-2@example.com |
+| user-id-3 | user-id-3@example.com |
++-----------+-----------------------+
+`, f.Run("team:users", "-t", "team1", "--columns", "id,email"))
+
+	assertTrimmed(t, `
+ID	Label	# Users	# Projects
+team1	Developers	2	1
+`, f.Run("teams", "-o", "acme", "--format", "plain", "--columns", "id,label,member_count,project_count"))
+
+	// Team members have the team's permissions on its projects.
+	users := f.Run("users", "-p", p, "--format", "plain", "--columns", "id,permissions")
+	assert.Contains(t, users, "user-id-2\tviewer, development:contributor\n")
+	assert.Contains(t, users, "user-id-3\tviewer, development:contributor\n")
+
+	_, stdErr, err = f.RunCombinedOutput("team:user:delete", "-t", "team1", "user-id-3")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "was successfully removed from the team Developers (team1).")
+	s.Recorder.AssertCount(t, 1, "DELETE", "/teams/team1/members/user-id-3")
+
+	_, stdErr, err = f.RunCombinedOutput("team:user:delete", "-t", "team1", "user-id-3")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The user user-id-3 was not found in the team Developers (team1)")
+
+	users = f.Run("users", "-p", p, "--format", "plain", "--columns", "id")
+	assert.Contains(t, users, "user-id-2\n")
+	assert.NotContains(t, users, "user-id-3")
+
+	_, stdErr, err = f.RunCombinedOutput("team:project:delete", "-t", "team1", p)
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "was successfully removed from the team Developers (team1).")
+	assert.NotContains(t, f.Run("users", "-p", p, "--format", "plain", "--columns", "id"), "user-id-2")
+
+	_, stdErr, err = f.RunCombinedOutput("team:project:delete", "-t", "team1", p)
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "The project ID "+p+" was not found in the team Developers (team1).")
+}
+
+func TestTeamUpdateAndDelete(t *testing.T) {
+	t.Parallel()
+	s := setupTeamTest(t).WithTeams(&teamFixture{ID: "team2", Label: "Admins", ProjectPermissions: []string{"admin"}})
+	f := s.Factory()
+
+	_, stdErr, err := f.RunCombinedOutput("team:update", "-t", "team1", "--label", "Devs", "--role", "admin")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Summary of changes:\nLabel: Developers -> Devs\nProject role: viewer -> admin\n")
+	s.Recorder.AssertOneJSON(t, "PATCH", "/teams/team1", `{"label": "Devs", "project_permissions": ["admin"]}`)
+
+	_, stdErr, err = f.RunCombinedOutput("team:update", "-t", "team1", "--label", "Devs", "--role", "admin")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "Nothing to update")
+	s.Recorder.AssertCount(t, 1, "PATCH", "/teams/team1")
+
+	_, stdErr, err = f.RunCombinedOutput("team:update", "-t", "team1", "--label", "admins")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Another team team2 exists in the organization with the same label: admins")
+
+	_, stdErr, err = f.RunCombinedOutput("team:delete", "-t", "team1")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "The team Devs (team1) was deleted.")
+	s.Recorder.AssertCount(t, 1, "DELETE", "/teams/team1")
+
+	_, stdErr, err = f.RunCombinedOutput("team:get", "-t", "team1")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "Team not found: team1")
+}
+
+func TestTeamNonAdmin(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t)
+	// The organization is owned by another user, and the current user is only a member.
+	s.Orgs = append(s.Orgs, makeOrg("org-id-2", "hooli", "Hooli", "owner-id", "flexible"))
+	s.Project.Organization = "org-id-2"
+	s.WithOrgMember("org-id-2", s.MyUserID).
+		WithOrgMember("org-id-2", "user-id-2").
+		WithTeams(&teamFixture{ID: "team1", Label: "Developers", ProjectPermissions: []string{"admin"}})
+	f := s.Factory()
+
+	_, stdErr, err := f.RunCombinedOutput("team:create", "-o", "hooli", "--label", "Admins", "--role", "admin")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "You do not have permission to manage teams in the organization Hooli (hooli).")
+
+	_, stdErr, err = f.RunCombinedOutput("teams", "-o", "hooli")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "You do not have permission to manage teams in the organization Hooli (hooli).")
+
+	// Without the organization option, the API itself refuses changes.
+	assertTrimmed(t, "Developers", f.Run("team:get", "-t", "team1", "-P", "label"))
+	_, stdErr, err = f.RunCombinedOutput("team:delete", "-t", "team1")
+	assertExitCode(t, 6, err)
+	assert.Contains(t, stdErr, "Permission denied.")
+	s.Recorder.AssertCount(t, 1, "DELETE", "/teams/team1")
+	assert.Equal(t, "Developers", s.Team("team1").Label)
+}
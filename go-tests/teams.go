@@ -0,0 +1,567 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/platformsh/cli/pkg/mockapi"
+)
+
+// teamFixture is a team in an organization, served by the teams stand-in.
+type teamFixture struct {
+	// ID defaults to "team" followed by a number.
+	ID string
+	// OrgID defaults to the project's organization.
+	OrgID string
+	Label string
+	// ProjectPermissions are the roles which members have on the team's
+	// projects, e.g. "viewer" and "development:contributor".
+	ProjectPermissions []string
+	// Members are user IDs, who need to be members of the organization.
+	Members []string
+	// Projects are the IDs of projects which the team has access to.
+	Projects  []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	// addedAt records when members and projects were added to the team.
+	addedAt map[string]time.Time
+}
+
+// teamsAPI stands in for the teams API. Organization members and the current
+// user's admin permission come from the scenario's grants.
+type teamsAPI struct {
+	s *scenario
+
+	mu    sync.Mutex
+	teams []*teamFixture
+}
+
+// WithTeams serves teams from a teamsAPI, and enables teams on organizations.
+// Organization members and the current user's admin permission come from the
+// scenario's grants (see WithOrg and WithOrgMember): only organization admins
+// can manage teams.
+//
+// Team members are granted the team's project permissions on the team's
+// projects, so that the mock API's user access reflects the teams.
+func (s *scenario) WithTeams(teams ...*teamFixture) *scenario {
+	if s.teams == nil {
+		s.teams = &teamsAPI{s: s}
+		s.serveOrgMembers()
+		s.Use(standIn(s.teams.routes))
+	}
+	s.teams.mu.Lock()
+	defer s.teams.mu.Unlock()
+	for _, team := range teams {
+		s.teams.add(team)
+	}
+	return s
+}
+
+// Team returns a team added with WithTeams or through the API.
+func (s *scenario) Team(id string) *teamFixture {
+	s.teams.mu.Lock()
+	defer s.teams.mu.Unlock()
+	if team := s.teams.find(id); team != nil {
+		return team
+	}
+	s.t.Fatalf("Team not found in scenario: %s", id)
+	return nil
+}
+
+func (api *teamsAPI) routes(r chi.Router) {
+	r.Get("/teams", api.handleList)
+	r.Post("/teams", api.handleCreate)
+	r.Get("/teams/{id}", api.handleGet)
+	r.Patch("/teams/{id}", api.handleUpdate)
+	r.Delete("/teams/{id}", api.handleDelete)
+	r.Get("/teams/{id}/members", api.handleListMembers)
+	r.Post("/teams/{id}/members", api.handleAddMember)
+	r.Get("/teams/{id}/members/{user}", api.handleGetMember)
+	r.Delete("/teams/{id}/members/{user}", api.handleDeleteMember)
+	r.Get("/teams/{id}/project-access", api.handleListProjects)
+	r.Post("/teams/{id}/project-access", api.handleAddProjects)
+	r.Delete("/teams/{id}/project-access/{project}", api.handleDeleteProject)
+	r.Get("/projects/{project}/team-access", api.handleListProjectTeams)
+}
+
+func (api *teamsAPI) add(team *teamFixture) {
+	if team.ID == "" {
+		for n := len(api.teams) + 1; team.ID == "" || api.find(team.ID) != nil; n++ {
+			team.ID = "team" + strconv.Itoa(n)
+		}
+	}
+	if team.OrgID == "" {
+		team.OrgID = api.s.Project.Organization
+	}
+	if team.ProjectPermissions == nil {
+		team.ProjectPermissions = []string{}
+	}
+	if team.CreatedAt.IsZero() {
+		team.CreatedAt, _ = time.Parse(time.RFC3339, "2014-04-01T10:00:00Z")
+	}
+	if team.UpdatedAt.IsZero() {
+		team.UpdatedAt = team.CreatedAt
+	}
+	if team.addedAt == nil {
+		team.addedAt = map[string]time.Time{}
+	}
+	api.teams = append(api.teams, team)
+}
+
+func (api *teamsAPI) find(id string) *teamFixture {
+	for _, team := range api.teams {
+		if team.ID == id {
+			return team
+		}
+	}
+	return nil
+}
+
+// removeOrgMember removes a user from the teams of an organization.
+func (api *teamsAPI) removeOrgMember(orgID, userID string) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	for _, team := range api.teams {
+		if team.OrgID == orgID {
+			team.Members = slices.DeleteFunc(team.Members, func(id string) bool { return id == userID })
+		}
+	}
+}
+
+// updateGrants sends users' access to the mock API, after grants or teams
+// have changed. No locks must be held.
+func (s *scenario) updateGrants() {
+	if s.teams != nil {
+		s.teams.mu.Lock()
+		defer s.teams.mu.Unlock()
+		s.teams.updateGrants()
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Handler.SetUserGrants(s.userGrants(nil))
+}
+
+// updateGrants sends users' access to the mock API. api.mu must be held.
+func (api *teamsAPI) updateGrants() {
+	api.s.mu.Lock()
+	defer api.s.mu.Unlock()
+	api.s.Handler.SetUserGrants(api.s.userGrants(api.teams))
+}
+
+// userGrants returns the scenario's grants, merged with the project access
+// which users have through teams. The scenario's lock must be held.
+func (s *scenario) userGrants(teams []*teamFixture) []*mockapi.UserGrant {
+	grants := make([]*mockapi.UserGrant, 0, len(s.Grants))
+	for _, g := range s.Grants {
+		c := *g
+		c.Permissions = slices.Clone(g.Permissions)
+		grants = append(grants, &c)
+	}
+	for _, team := range teams {
+		for _, projectID := range team.Projects {
+			for _, userID := range team.Members {
+				i := slices.IndexFunc(grants, func(g *mockapi.UserGrant) bool {
+					return g.ResourceType == "project" && g.ResourceID == projectID && g.UserID == userID
+				})
+				if i == -1 {
+					grants = append(grants, &mockapi.UserGrant{
+						ResourceID:     projectID,
+						ResourceType:   "project",
+						OrganizationID: team.OrgID,
+						UserID:         userID,
+					})
+					i = len(grants) - 1
+				}
+				for _, perm := range team.ProjectPermissions {
+					if !slices.Contains(grants[i].Permissions, perm) {
+						grants[i].Permissions = append(grants[i].Permissions, perm)
+					}
+				}
+			}
+		}
+	}
+	return grants
+}
+
+// teamChanged updates the mock API's user access after a team's members or
+// projects have changed.
+func (api *teamsAPI) teamChanged(team *teamFixture) {
+	team.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	api.updateGrants()
+}
+
+// requireOrgAdmin writes an error response unless the current user can
+// manage teams in an organization.
+func (api *teamsAPI) requireOrgAdmin(w http.ResponseWriter, orgID string) bool {
+	s := api.s
+	s.mu.Lock()
+	admin := s.isOrgAdmin(orgID, s.MyUserID)
+	s.mu.Unlock()
+	if !admin {
+		writeJSON(w, http.StatusForbidden, map[string]any{"message": "You do not have permission to manage teams in this organization."})
+		return false
+	}
+	return true
+}
+
+// teamFromRequest returns the team in the request's URL, or writes a "not
+// found" response.
+func (api *teamsAPI) teamFromRequest(w http.ResponseWriter, req *http.Request) *teamFixture {
+	team := api.find(chi.URLParam(req, "id"))
+	if team == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Team not found"})
+	}
+	return team
+}
+
+func (api *teamsAPI) handleList(w http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+	var ids []string
+	if in := q.Get("filter[id][in]"); in != "" {
+		ids = strings.Split(in, ",")
+	}
+	sortBy := q.Get("sort")
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	var teams []*teamFixture
+	for _, team := range api.teams {
+		if orgID := q.Get("filter[organization_id]"); orgID != "" && team.OrgID != orgID {
+			continue
+		}
+		if ids != nil && !slices.Contains(ids, team.ID) {
+			continue
+		}
+		teams = append(teams, team)
+	}
+	if strings.TrimPrefix(sortBy, "-") == "label" {
+		slices.SortStableFunc(teams, func(a, b *teamFixture) int {
+			return strings.Compare(strings.ToLower(a.Label), strings.ToLower(b.Label))
+		})
+	}
+	if strings.HasPrefix(sortBy, "-") {
+		slices.Reverse(teams)
+	}
+	items := []any{}
+	for _, team := range teams {
+		items = append(items, teamData(team))
+	}
+	writeJSON(w, http.StatusOK, collectionData(items, req, nil))
+}
+
+func (api *teamsAPI) handleGet(w http.ResponseWriter, req *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if team := api.teamFromRequest(w, req); team != nil {
+		writeJSON(w, http.StatusOK, teamData(team))
+	}
+}
+
+// teamParams are the properties of a team sent by the CLI.
+type teamParams struct {
+	OrganizationID     string    `json:"organization_id"`
+	Label              *string   `json:"label"`
+	ProjectPermissions *[]string `json:"project_permissions"`
+}
+
+func (api *teamsAPI) handleCreate(w http.ResponseWriter, req *http.Request) {
+	var params teamParams
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil || params.Label == nil || *params.Label == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": "Invalid team parameters"})
+		return
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if !api.s.hasOrg(params.OrganizationID) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": "Organization not found"})
+		return
+	}
+	if !api.requireOrgAdmin(w, params.OrganizationID) || !api.checkLabel(w, params.OrganizationID, *params.Label, "") {
+		return
+	}
+	team := &teamFixture{
+		OrgID:     params.OrganizationID,
+		Label:     *params.Label,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		UpdatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	if params.ProjectPermissions != nil {
+		team.ProjectPermissions = *params.ProjectPermissions
+	}
+	api.add(team)
+	writeJSON(w, http.StatusCreated, teamData(team))
+}
+
+func (api *teamsAPI) handleUpdate(w http.ResponseWriter, req *http.Request) {
+	var params teamParams
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil || (params.Label != nil && *params.Label == "") {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": "Invalid team parameters"})
+		return
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	team := api.teamFromRequest(w, req)
+	if team == nil || !api.requireOrgAdmin(w, team.OrgID) {
+		return
+	}
+	if params.Label != nil {
+		if !api.checkLabel(w, team.OrgID, *params.Label, team.ID) {
+			return
+		}
+		team.Label = *params.Label
+	}
+	if params.ProjectPermissions != nil {
+		team.ProjectPermissions = *params.ProjectPermissions
+	}
+	api.teamChanged(team)
+	writeJSON(w, http.StatusOK, teamData(team))
+}
+
+// checkLabel writes a conflict response if another team in the
+// organization has the same label.
+func (api *teamsAPI) checkLabel(w http.ResponseWriter, orgID, label, exceptID string) bool {
+	for _, other := range api.teams {
+		if other.OrgID == orgID && other.ID != exceptID && strings.EqualFold(other.Label, label) {
+			writeJSON(w, http.StatusConflict, map[string]any{"message": "A team with the same label already exists"})
+			return false
+		}
+	}
+	return true
+}
+
+func (api *teamsAPI) handleDelete(w http.ResponseWriter, req *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	team := api.teamFromRequest(w, req)
+	if team == nil || !api.requireOrgAdmin(w, team.OrgID) {
+		return
+	}
+	api.teams = slices.DeleteFunc(api.teams, func(other *teamFixture) bool {
+		return other == team
+	})
+	api.updateGrants()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *teamsAPI) handleListMembers(w http.ResponseWriter, req *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	team := api.teamFromRequest(w, req)
+	if team == nil {
+		return
+	}
+	items := []any{}
+	for _, userID := range team.Members {
+		items = append(items, teamMemberData(team, userID))
+	}
+	writeJSON(w, http.StatusOK, collectionData(items, req, team.Members))
+}
+
+func (api *teamsAPI) handleGetMember(w http.ResponseWriter, req *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	team := api.teamFromRequest(w, req)
+	if team == nil {
+		return
+	}
+	userID := chi.URLParam(req, "user")
+	if !slices.Contains(team.Members, userID) {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Team member not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, teamMemberData(team, userID))
+}
+
+func (api *teamsAPI) handleAddMember(w http.ResponseWriter, req *http.Request) {
+	var params struct {
+		UserID string `json:"user_id"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil || params.UserID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": "Invalid team member parameters"})
+		return
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	team := api.teamFromRequest(w, req)
+	if team == nil || !api.requireOrgAdmin(w, team.OrgID) {
+		return
+	}
+	if !api.s.isOrgMember(team.OrgID, params.UserID) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": "The user is not a member of the organization"})
+		return
+	}
+	if slices.Contains(team.Members, params.UserID) {
+		writeJSON(w, http.StatusConflict, map[string]any{"message": "The user is already a member of the team"})
+		return
+	}
+	team.Members = append(team.Members, params.UserID)
+	team.addedAt["user:"+params.UserID] = time.Now().UTC().Truncate(time.Second)
+	api.teamChanged(team)
+	writeJSON(w, http.StatusCreated, teamMemberData(team, params.UserID))
+}
+
+func (api *teamsAPI) handleDeleteMember(w http.ResponseWriter, req *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	team := api.teamFromRequest(w, req)
+	if team == nil || !api.requireOrgAdmin(w, team.OrgID) {
+		return
+	}
+	userID := chi.URLParam(req, "user")
+	if !slices.Contains(team.Members, userID) {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Team member not found"})
+		return
+	}
+	team.Members = slices.DeleteFunc(team.Members, func(id string) bool { return id == userID })
+	api.teamChanged(team)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *teamsAPI) handleListProjects(w http.ResponseWriter, req *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	team := api.teamFromRequest(w, req)
+	if team == nil {
+		return
+	}
+	items := []any{}
+	for _, projectID := range team.Projects {
+		items = append(items, api.teamProjectData(team, projectID))
+	}
+	writeJSON(w, http.StatusOK, collectionData(items, req, nil))
+}
+
+func (api *teamsAPI) handleAddProjects(w http.ResponseWriter, req *http.Request) {
+	var params []struct {
+		ProjectID string `json:"project_id"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil || len(params) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": "Invalid team project parameters"})
+		return
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	team := api.teamFromRequest(w, req)
+	if team == nil || !api.requireOrgAdmin(w, team.OrgID) {
+		return
+	}
+	for _, p := range params {
+		if p.ProjectID != api.s.ProjectID || api.s.Project.Organization != team.OrgID {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"message": "Project not found in the organization: " + p.ProjectID})
+			return
+		}
+	}
+	for _, p := range params {
+		if !slices.Contains(team.Projects, p.ProjectID) {
+			team.Projects = append(team.Projects, p.ProjectID)
+			team.addedAt["project:"+p.ProjectID] = time.Now().UTC().Truncate(time.Second)
+		}
+	}
+	api.teamChanged(team)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *teamsAPI) handleDeleteProject(w http.ResponseWriter, req *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	team := api.teamFromRequest(w, req)
+	if team == nil || !api.requireOrgAdmin(w, team.OrgID) {
+		return
+	}
+	projectID := chi.URLParam(req, "project")
+	if !slices.Contains(team.Projects, projectID) {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Team project access not found"})
+		return
+	}
+	team.Projects = slices.DeleteFunc(team.Projects, func(id string) bool { return id == projectID })
+	api.teamChanged(team)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *teamsAPI) handleListProjectTeams(w http.ResponseWriter, req *http.Request) {
+	projectID := chi.URLParam(req, "project")
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	items := []any{}
+	for _, team := range api.teams {
+		if slices.Contains(team.Projects, projectID) {
+			items = append(items, api.teamProjectData(team, projectID))
+		}
+	}
+	writeJSON(w, http.StatusOK, collectionData(items, req, nil))
+}
+
+// teamData returns the API representation of a team.
+func teamData(team *teamFixture) map[string]any {
+	self := "/teams/" + url.PathEscape(team.ID)
+	return map[string]any{
+		"id":                  team.ID,
+		"organization_id":     team.OrgID,
+		"label":               team.Label,
+		"project_permissions": team.ProjectPermissions,
+		"counts": map[string]any{
+			"member_count":  len(team.Members),
+			"project_count": len(team.Projects),
+		},
+		"created_at": team.CreatedAt.Format(time.RFC3339),
+		"updated_at": team.UpdatedAt.Format(time.RFC3339),
+		"_links": map[string]any{
+			"self":    map[string]any{"href": self},
+			"#edit":   map[string]any{"href": self},
+			"#delete": map[string]any{"href": self},
+		},
+	}
+}
+
+// teamMemberData returns the API representation of a user in a team.
+func teamMemberData(team *teamFixture, userID string) map[string]any {
+	self := "/teams/" + url.PathEscape(team.ID) + "/members/" + url.PathEscape(userID)
+	added := team.addedAt["user:"+userID]
+	if added.IsZero() {
+		added = team.CreatedAt
+	}
+	return map[string]any{
+		"team_id":    team.ID,
+		"user_id":    userID,
+		"created_at": added.Format(time.RFC3339),
+		"updated_at": added.Format(time.RFC3339),
+		"_links": map[string]any{
+			"self":    map[string]any{"href": self},
+			"#delete": map[string]any{"href": self},
+		},
+	}
+}
+
+// teamProjectData returns the API representation of a team's access to a project.
+func (api *teamsAPI) teamProjectData(team *teamFixture, projectID string) map[string]any {
+	self := "/teams/" + url.PathEscape(team.ID) + "/project-access/" + url.PathEscape(projectID)
+	granted := team.addedAt["project:"+projectID]
+	if granted.IsZero() {
+		granted = team.CreatedAt
+	}
+	title := ""
+	if projectID == api.s.ProjectID {
+		title = api.s.Project.Title
+	}
+	return map[string]any{
+		"team_id":         team.ID,
+		"organization_id": team.OrgID,
+		"project_id":      projectID,
+		"project_title":   title,
+		"granted_at":      granted.Format(time.RFC3339),
+		"updated_at":      granted.Format(time.RFC3339),
+		"_links": map[string]any{
+			"self":    map[string]any{"href": self},
+			"#delete": map[string]any{"href": self},
+		},
+	}
+}
@@ -2,6 +2,9 @@
 //
 // A TEST_CLI_PATH environment variable can be provided to override the path to a
 // CLI executable. It defaults to `bin/platform` in the repository root.
+//
+// Expected output can be kept in golden files under testdata/; run the tests
+// with the -update flag to regenerate them.
 package tests
 
 import (
@@ -11,6 +14,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -19,12 +23,17 @@ import (
 	"github.com/platformsh/cli/pkg/mockapi"
 )
 
-var _validatedCommand string
+var (
+	_validatedCommand string
+	validateMutex     sync.Mutex
+)
 
 func getCommandName(t *testing.T) string {
 	if testing.Short() {
 		t.Skip("skipping integration test due to -short flag")
 	}
+	validateMutex.Lock()
+	defer validateMutex.Unlock()
 	if _validatedCommand != "" {
 		return _validatedCommand
 	}
@@ -43,7 +52,7 @@ func getCommandName(t *testing.T) string {
 		candidate = c
 	}
 	versionCmd := exec.Command(candidate, "--version")
-	versionCmd.Env = testEnv()
+	versionCmd.Env = append(testEnv(), EnvPrefix+"HOME="+t.TempDir())
 	output, err := versionCmd.Output()
 	require.NoError(t, err, "running '--version' must succeed under the CLI at: %s", candidate)
 	require.Contains(t, string(output), "Platform Test CLI ")
@@ -57,6 +66,13 @@ type cmdFactory struct {
 	apiURL   string
 	authURL  string
 	extraEnv []string
+	masks    []string
+
+	// The CLI's home directory (containing its config, session and cache),
+	// and the working directory for commands. These default to temporary
+	// directories per factory, so that tests can run in parallel.
+	homeDir string
+	workDir string
 }
 
 func newCommandFactory(t *testing.T, apiURL, authURL string) *cmdFactory {
@@ -74,7 +90,16 @@ func (f *cmdFactory) Run(args ...string) string {
 
 // RunCombinedOutput runs a command and returns its stdout, stderr and the error.
 func (f *cmdFactory) RunCombinedOutput(args ...string) (string, string, error) {
+	return f.RunWithInput("", args...)
+}
+
+// RunWithInput runs a command with the given standard input, and returns its
+// stdout, stderr and the error.
+func (f *cmdFactory) RunWithInput(input string, args ...string) (string, string, error) {
 	cmd := f.buildCommand(args...)
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
 	var stdOutBuffer bytes.Buffer
 	var stdErrBuffer bytes.Buffer
 	cmd.Stdout = &stdOutBuffer
@@ -89,8 +114,9 @@ func (f *cmdFactory) RunCombinedOutput(args ...string) (string, string, error) {
 
 func (f *cmdFactory) buildCommand(args ...string) *exec.Cmd {
 	cmd := exec.Command(getCommandName(f.t), args...) //nolint:gosec
-	cmd.Env = testEnv()
-	cmd.Dir = os.TempDir()
+	f.initDirs()
+	cmd.Env = append(testEnv(), EnvPrefix+"HOME="+f.homeDir)
+	cmd.Dir = f.workDir
 	if testing.Verbose() {
 		cmd.Stderr = os.Stderr
 	}
@@ -104,6 +130,40 @@ func (f *cmdFactory) buildCommand(args ...string) *exec.Cmd {
 	return cmd
 }
 
+// WorkDir returns the working directory for commands.
+func (f *cmdFactory) WorkDir() string {
+	f.initDirs()
+	return f.workDir
+}
+
+// InDir returns a copy of the factory that runs commands in another working
+// directory, e.g. a Git repository. It shares the CLI home directory, so the
+// session and cache are shared too.
+func (f *cmdFactory) InDir(dir string) *cmdFactory {
+	f.initDirs()
+	c := *f
+	c.workDir = dir
+	return &c
+}
+
+// WithExtraEnv returns a copy of the factory that runs commands with extra
+// environment variables. Like InDir, it shares the CLI home directory.
+func (f *cmdFactory) WithExtraEnv(env ...string) *cmdFactory {
+	f.initDirs()
+	c := *f
+	c.extraEnv = append(append([]string(nil), f.extraEnv...), env...)
+	return &c
+}
+
+func (f *cmdFactory) initDirs() {
+	if f.homeDir == "" {
+		f.homeDir = f.t.TempDir()
+	}
+	if f.workDir == "" {
+		f.workDir = f.t.TempDir()
+	}
+}
+
 func assertTrimmed(t *testing.T, expected, actual string) {
 	assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(actual))
 }
@@ -121,7 +181,6 @@ func testEnv() []string {
 		"CLI_CONFIG_FILE="+configPath,
 		EnvPrefix+"NO_INTERACTION=1",
 		EnvPrefix+"VERSION=1.0.0",
-		EnvPrefix+"HOME="+os.TempDir(),
 		"TZ=UTC",
 	)
 }
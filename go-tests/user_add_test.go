@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupUserTest creates a scenario with a project in an organization, two
+// other project users, and a pending invitation.
+func setupUserTest(t *testing.T) *scenario {
+	return newScenario(t).
+		WithOrg("org-id-1", "acme", "ACME Inc.").
+		WithProjectUser("user-id-2", "viewer", "development:viewer").
+		WithProjectUser("user-id-3", "viewer", "production:viewer", "development:admin", "staging:contributor").
+		WithUserAccess(&projectInvitationFixture{Email: "pending@example.com", Permissions: []string{"staging:viewer"}})
+}
+
+func TestUserAdd(t *testing.T) {
+	t.Parallel()
+	s := setupUserTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	// Unknown email addresses are invited, with environment type roles
+	// matched by wildcard and abbreviated.
+	_, stdErr, err := f.RunCombinedOutput("user:add", "-p", p, "new@example.com", "-r", "viewer", "-r", "production:v,dev%:admin")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Adding the user new@example.com to Project 1 ("+p+"):\n"+
+		"  Project role: viewer\n"+
+		"    Role on type development: admin\n"+
+		"    Role on type production: viewer\n")
+	assert.Contains(t, stdErr, "Adding users can result in additional charges.")
+	assert.Contains(t, stdErr, "An invitation has been sent to new@example.com")
+	body := s.Recorder.RequireOne(t, "POST", "/projects/"+p+"/invitations").JSON(t)
+	assert.Equal(t, "new@example.com", body["email"])
+	assert.Equal(t, "viewer", body["role"])
+	assert.Equal(t, []any{
+		map[string]any{"type": "development", "role": "admin"},
+		map[string]any{"type": "production", "role": "viewer"},
+	}, body["permissions"])
+	require.Len(t, s.ProjectInvitations("new@example.com"), 1)
+	assert.Equal(t, []string{"development:admin", "production:viewer"}, s.ProjectInvitations("new@example.com")[0].Permissions)
+
+	// A pending invitation is replaced after confirmation.
+	s.Recorder.Reset()
+	_, stdErr, err = f.RunCombinedOutput("user:add", "-p", p, "pending@example.com", "-r", "admin")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "An invitation has already been sent to pending@example.com")
+	assert.Contains(t, stdErr, "A new invitation has been sent to pending@example.com")
+	invites := s.Recorder.Find("POST", "/projects/"+p+"/invitations")
+	require.Len(t, invites, 2)
+	assert.Equal(t, true, invites[1].JSON(t)["force"])
+	assert.Equal(t, "admin", invites[1].JSON(t)["role"])
+	assert.Equal(t, "cancelled", s.ProjectInvitations("pending@example.com")[0].State)
+
+	// Invalid roles are rejected before anything is sent.
+	s.Recorder.Reset()
+	_, stdErr, err = f.RunCombinedOutput("user:add", "-p", p, "other@example.com", "-r", "admin", "-r", "production:viewer")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "A project admin has administrative access to all environment types.")
+
+	_, stdErr, err = f.RunCombinedOutput("user:add", "-p", p, "other@example.com", "-r", "viewer")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "A non-admin user must be added to at least one environment type.")
+
+	_, stdErr, err = f.RunCombinedOutput("user:add", "-p", p, "other@example.com", "-r", "production:superuser")
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "Invalid role: superuser")
+
+	_, stdErr, err = f.RunCombinedOutput("user:add", "-p", p, "not-an-email", "-r", "admin")
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "Invalid email address: not-an-email")
+	s.Recorder.AssertNone(t, "POST", "/invitations")
+}
+
+func TestUserUpdate(t *testing.T) {
+	t.Parallel()
+	s := setupUserTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	_, stdErr, err := f.RunCombinedOutput("user:update", "-p", p, "user-id-2@example.com", "-r", "production:contributor,development:none")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Current role(s) of User user-id-2 (user-id-2@example.com) on Project 1 ("+p+"):\n"+
+		"  Project role: viewer\n"+
+		"    Role on environment type development: viewer\n"+
+		"    Role on environment type production: [none]\n"+
+		"    Role on environment type staging: [none]\n")
+	assert.Contains(t, stdErr, "Summary of changes:\n"+
+		"    Role on type development: viewer -> none\n"+
+		"    Role on type production: none -> contributor\n")
+	assert.Contains(t, stdErr, "Access was updated successfully.")
+	s.Recorder.AssertOneJSON(t, "PATCH", "/projects/"+p+"/user-access/user-id-2", `{"permissions": ["viewer", "production:contributor"]}`)
+
+	// Roles on types which are not specified are kept.
+	_, stdErr, err = f.RunCombinedOutput("user:update", "-p", p, "user-id-3", "-r", "%:viewer")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Role on type development: admin -> viewer")
+	assert.Contains(t, stdErr, "Role on type staging: contributor -> viewer")
+	s.Recorder.AssertOneJSON(t, "PATCH", "/projects/"+p+"/user-access/user-id-3",
+		`{"permissions": ["viewer", "development:viewer", "production:viewer", "staging:viewer"]}`)
+
+	_, stdErr, err = f.RunCombinedOutput("user:update", "-p", p, "user-id-3@example.com", "-r", "admin")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Project role: viewer -> admin")
+	assertTrimmed(t, "admin", f.Run("user:get", "-p", p, "user-id-3@example.com", "--pipe"))
+
+	users := f.Run("users", "-p", p, "--format", "plain", "--columns", "id,permissions")
+	assert.Contains(t, users, "user-id-2\tviewer, production:contributor\n")
+	assert.Contains(t, users, "user-id-3\tadmin\n")
+
+	// Nothing is sent if the roles are unchanged.
+	s.Recorder.Reset()
+	_, _, err = f.RunCombinedOutput("user:update", "-p", p, "user-id-2@example.com", "-r", "production:contributor")
+	assert.NoError(t, err)
+	s.Recorder.AssertNone(t, "PATCH", "/user-access/user-id-2")
+
+	_, stdErr, err = f.RunCombinedOutput("user:update", "-p", p, "user-id-2@example.com", "-r", "production:none")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "A non-admin user must be added to at least one environment type.")
+	assert.Contains(t, stdErr, "To delete the user, run: platform-test user:delete user-id-2@example.com")
+
+	_, stdErr, err = f.RunCombinedOutput("user:update", "-p", p, "nobody@example.com", "-r", "admin")
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "User not found: nobody@example.com")
+	s.Recorder.AssertNone(t, "PATCH", "/user-access/user-id-2")
+	s.Recorder.AssertNone(t, "POST", "/invitations")
+}
+
+func TestUserDelete(t *testing.T) {
+	t.Parallel()
+	s := setupUserTest(t)
+	f, p := s.Factory(), s.ProjectID
+
+	// Declining the confirmation keeps the user.
+	session := f.RunInteractive("user:delete", "-p", p, "user-id-3@example.com")
+	session.Expect("Are you sure you want to delete the user user-id-3@example.com? [Y/n]")
+	session.SendLine("n")
+	assert.Error(t, session.Wait())
+	s.Recorder.AssertNone(t, "DELETE", "/user-access/user-id-3")
+
+	_, stdErr, err := f.RunCombinedOutput("user:delete", "-p", p, "user-id-3@example.com")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Are you sure you want to delete the user user-id-3@example.com? [Y/n] y")
+	assert.Contains(t, stdErr, "User user-id-3@example.com deleted")
+	s.Recorder.AssertCount(t, 1, "DELETE", "/projects/"+p+"/user-access/user-id-3")
+
+	users := f.Run("users", "-p", p, "--format", "plain", "--columns", "id")
+	assert.Contains(t, users, "user-id-2\n")
+	assert.NotContains(t, users, "user-id-3")
+
+	_, stdErr, err = f.RunCombinedOutput("user:delete", "-p", p, "user-id-3@example.com")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "User not found: user-id-3@example.com")
+	s.Recorder.AssertCount(t, 1, "DELETE", "/projects/"+p+"/user-access/user-id-3")
+}
@@ -8,6 +8,7 @@ import (
 )
 
 func TestUserList(t *testing.T) {
+	t.Parallel()
 	authServer := mockapi.NewAuthServer(t)
 	defer authServer.Close()
 
@@ -59,20 +60,7 @@ func TestUserList(t *testing.T) {
 
 	f := newCommandFactory(t, apiServer.URL, authServer.URL)
 
-	assertTrimmed(t, `
-+------------------------+-----------------+--------------+------------+
-| Email address          | Name            | Project role | ID         |
-+------------------------+-----------------+--------------+------------+
-| my-user-id@example.com | User my-user-id | admin        | my-user-id |
-| user-id-2@example.com  | User user-id-2  | viewer       | user-id-2  |
-| user-id-3@example.com  | User user-id-3  | viewer       | user-id-3  |
-+------------------------+-----------------+--------------+------------+
-`, f.Run("users", "-p", projectID))
+	f.RunGolden("users", "users", "-p", projectID)
 
-	assertTrimmed(t, `
-Email address	Name	Project role	ID	Permissions
-my-user-id@example.com	User my-user-id	admin	my-user-id	admin
-user-id-2@example.com	User user-id-2	viewer	user-id-2	viewer, development:viewer
-user-id-3@example.com	User user-id-3	viewer	user-id-3	viewer, production:viewer, development:admin, staging:contributor
-`, f.Run("users", "-p", projectID, "--format", "plain", "--columns", "+perm%"))
+	f.RunGolden("users-permissions", "users", "-p", projectID, "--format", "plain", "--columns", "+perm%")
 }
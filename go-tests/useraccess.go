@@ -0,0 +1,301 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/platformsh/cli/pkg/mockapi"
+)
+
+// environmentTypes are the project's environment types, in the API's order.
+var environmentTypes = []string{"development", "production", "staging"}
+
+// environmentTypeRoles are the roles a user can have on an environment type.
+var environmentTypeRoles = []string{"admin", "contributor", "viewer"}
+
+// projectInvitationFixture is an invitation to join the scenario's project.
+type projectInvitationFixture struct {
+	// ID defaults to "project-invite" followed by a number.
+	ID    string
+	Email string
+	// Role is the project role, "admin" or "viewer".
+	Role string
+	// Permissions are environment type roles, e.g. "production:viewer".
+	Permissions []string
+	// State is "pending" (the default), "accepted" or "cancelled".
+	State     string
+	CreatedAt time.Time
+}
+
+// userAccessAPI stands in for the centralized permissions API. Users' project
+// permissions come from the scenario's grants, which the scenario's lock guards.
+type userAccessAPI struct {
+	s *scenario
+
+	mu          sync.Mutex
+	invitations []*projectInvitationFixture
+}
+
+// WithUserAccess serves changes to users' access to the project, and project
+// invitations, from a userAccessAPI, and adds invitations.
+//
+// Users' project permissions come from the scenario's grants, which
+// user:update and user:delete change. Only one invitation can be pending for
+// an email address, unless the invitation is forced.
+func (s *scenario) WithUserAccess(invitations ...*projectInvitationFixture) *scenario {
+	if s.userAccess == nil {
+		s.userAccess = &userAccessAPI{s: s}
+		s.Use(standIn(s.userAccess.routes))
+	}
+	s.userAccess.mu.Lock()
+	defer s.userAccess.mu.Unlock()
+	for _, inv := range invitations {
+		s.userAccess.addInvitation(inv)
+	}
+	return s
+}
+
+// WithProjectUser grants a user access to the scenario's project, with
+// permissions such as "viewer" and "production:contributor".
+func (s *scenario) WithProjectUser(userID string, permissions ...string) *scenario {
+	s.Grants = append(s.Grants, &mockapi.UserGrant{
+		ResourceID:     s.ProjectID,
+		ResourceType:   "project",
+		OrganizationID: s.Project.Organization,
+		UserID:         userID,
+		Permissions:    permissions,
+	})
+	return s
+}
+
+// ProjectInvitations returns the project invitations for an email address.
+func (s *scenario) ProjectInvitations(email string) []*projectInvitationFixture {
+	s.userAccess.mu.Lock()
+	defer s.userAccess.mu.Unlock()
+	var found []*projectInvitationFixture
+	for _, inv := range s.userAccess.invitations {
+		if strings.EqualFold(inv.Email, email) {
+			found = append(found, inv)
+		}
+	}
+	return found
+}
+
+func (api *userAccessAPI) routes(r chi.Router) {
+	r.Get("/projects/{project}/environment-types", api.handleListEnvironmentTypes)
+	r.Patch("/projects/{project}/user-access/{user}", api.handleUpdate)
+	r.Delete("/projects/{project}/user-access/{user}", api.handleDelete)
+	r.Post("/projects/{project}/invitations", api.handleCreateInvitation)
+}
+
+func (api *userAccessAPI) addInvitation(inv *projectInvitationFixture) {
+	if inv.ID == "" {
+		inv.ID = "project-invite" + strconv.Itoa(len(api.invitations)+1)
+	}
+	if inv.Role == "" {
+		inv.Role = "viewer"
+	}
+	if inv.Permissions == nil {
+		inv.Permissions = []string{}
+	}
+	if inv.State == "" {
+		inv.State = "pending"
+	}
+	if inv.CreatedAt.IsZero() {
+		inv.CreatedAt, _ = time.Parse(time.RFC3339, "2014-04-01T10:00:00Z")
+	}
+	api.invitations = append(api.invitations, inv)
+}
+
+// applyProjectLinks sets the links of the project which depend on stand-ins.
+func (s *scenario) applyProjectLinks() {
+	if s.userAccess != nil {
+		projectPath := "/projects/" + url.PathEscape(s.ProjectID)
+		s.Project.Links["environment-types"] = mockapi.HALLink{HREF: projectPath + "/environment-types"}
+		s.Project.Links["invitations"] = mockapi.HALLink{HREF: projectPath + "/invitations"}
+	}
+}
+
+// findProjectGrant returns a user's direct grant on a project.
+func (s *scenario) findProjectGrant(projectID, userID string) *mockapi.UserGrant {
+	for _, g := range s.Grants {
+		if g.ResourceType == "project" && g.ResourceID == projectID && g.UserID == userID {
+			return g
+		}
+	}
+	return nil
+}
+
+// checkProjectPermissions writes an error response unless a list of
+// permissions contains one project role ("admin" or "viewer"), followed by
+// environment type roles for a viewer.
+func checkProjectPermissions(w http.ResponseWriter, permissions []string) bool {
+	var projectRoles []string
+	for _, p := range permissions {
+		typ, role, ok := strings.Cut(p, ":")
+		if !ok {
+			projectRoles = append(projectRoles, p)
+			continue
+		}
+		if !slices.Contains(environmentTypes, typ) || !slices.Contains(environmentTypeRoles, role) {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"detail": "Invalid permission: " + p})
+			return false
+		}
+	}
+	if len(projectRoles) != 1 || (projectRoles[0] != "admin" && projectRoles[0] != "viewer") {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"detail": "Exactly one project role (admin or viewer) is required."})
+		return false
+	}
+	if projectRoles[0] == "admin" && len(permissions) > 1 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"detail": "Project admins cannot have environment type roles."})
+		return false
+	}
+	return true
+}
+
+func (api *userAccessAPI) handleListEnvironmentTypes(w http.ResponseWriter, req *http.Request) {
+	types := make([]any, 0, len(environmentTypes))
+	for _, id := range environmentTypes {
+		self := req.URL.Path + "/" + url.PathEscape(id)
+		types = append(types, map[string]any{
+			"id": id,
+			"_links": map[string]any{
+				"self":   map[string]any{"href": self},
+				"access": map[string]any{"href": self + "/access"},
+			},
+		})
+	}
+	writeJSON(w, http.StatusOK, types)
+}
+
+func (api *userAccessAPI) handleUpdate(w http.ResponseWriter, req *http.Request) {
+	var params struct {
+		Permissions []string `json:"permissions"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil || params.Permissions == nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"detail": "Invalid user access parameters"})
+		return
+	}
+	s := api.s
+	s.mu.Lock()
+	g := s.findProjectGrant(chi.URLParam(req, "project"), chi.URLParam(req, "user"))
+	if g == nil {
+		s.mu.Unlock()
+		writeJSON(w, http.StatusNotFound, map[string]any{"detail": "User access not found"})
+		return
+	}
+	if !checkProjectPermissions(w, params.Permissions) {
+		s.mu.Unlock()
+		return
+	}
+	g.Permissions = params.Permissions
+	data := userAccessData(g)
+	s.mu.Unlock()
+	s.updateGrants()
+	writeJSON(w, http.StatusOK, data)
+}
+
+func (api *userAccessAPI) handleDelete(w http.ResponseWriter, req *http.Request) {
+	s := api.s
+	s.mu.Lock()
+	g := s.findProjectGrant(chi.URLParam(req, "project"), chi.URLParam(req, "user"))
+	if g == nil {
+		s.mu.Unlock()
+		writeJSON(w, http.StatusNotFound, map[string]any{"detail": "User access not found"})
+		return
+	}
+	s.Grants = slices.DeleteFunc(s.Grants, func(other *mockapi.UserGrant) bool {
+		return other == g
+	})
+	s.mu.Unlock()
+	s.updateGrants()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleCreateInvitation invites a user by email address, with a
+// project role and environment type permissions (a list of type/role pairs).
+// A pending invitation for the same email address is a conflict, unless
+// "force" is set, which replaces it.
+func (api *userAccessAPI) handleCreateInvitation(w http.ResponseWriter, req *http.Request) {
+	var params struct {
+		Email       string `json:"email"`
+		Role        string `json:"role"`
+		Permissions []struct {
+			Type string `json:"type"`
+			Role string `json:"role"`
+		} `json:"permissions"`
+		Force bool `json:"force"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil || params.Email == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"detail": "Invalid invitation parameters"})
+		return
+	}
+	permissions := []string{params.Role}
+	for _, p := range params.Permissions {
+		permissions = append(permissions, p.Type+":"+p.Role)
+	}
+	if !checkProjectPermissions(w, permissions) {
+		return
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	for _, other := range api.invitations {
+		if other.State != "pending" || !strings.EqualFold(other.Email, params.Email) {
+			continue
+		}
+		if !params.Force {
+			writeJSON(w, http.StatusConflict, map[string]any{"detail": "An invitation has already been sent to this email address."})
+			return
+		}
+		other.State = "cancelled"
+	}
+	inv := &projectInvitationFixture{
+		Email:       params.Email,
+		Role:        params.Role,
+		Permissions: permissions[1:],
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+	api.addInvitation(inv)
+	typePermissions := make([]any, 0, len(params.Permissions))
+	for _, p := range params.Permissions {
+		typePermissions = append(typePermissions, map[string]any{"type": p.Type, "role": p.Role})
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"id":          inv.ID,
+		"state":       inv.State,
+		"email":       inv.Email,
+		"role":        inv.Role,
+		"permissions": typePermissions,
+		"owner":       map[string]any{"id": api.s.MyUserID},
+		"created_at":  inv.CreatedAt.Format(time.RFC3339),
+		"updated_at":  inv.CreatedAt.Format(time.RFC3339),
+		"finished_at": nil,
+	})
+}
+
+// userAccessData returns the API representation of a user's project access.
+func userAccessData(g *mockapi.UserGrant) map[string]any {
+	self := "/projects/" + url.PathEscape(g.ResourceID) + "/user-access/" + url.PathEscape(g.UserID)
+	granted, _ := time.Parse(time.RFC3339, "2014-04-01T10:00:00Z")
+	return map[string]any{
+		"project_id":      g.ResourceID,
+		"user_id":         g.UserID,
+		"organization_id": g.OrganizationID,
+		"permissions":     g.Permissions,
+		"granted_at":      granted.Format(time.RFC3339),
+		"updated_at":      granted.Format(time.RFC3339),
+		"_links": map[string]any{
+			"self":   map[string]any{"href": self},
+			"update": map[string]any{"href": self},
+			"delete": map[string]any{"href": self},
+		},
+	}
+}
@@ -1,11 +1,8 @@
 package tests
 
 import (
-	"encoding/base64"
-	"encoding/json"
 	"fmt"
 	"net/http/httptest"
-	"net/url"
 	"strconv"
 	"strings"
 	"testing"
@@ -15,10 +12,10 @@ import (
 	"github.com/platformsh/cli/pkg/mockapi"
 	"github.com/platformsh/cli/pkg/mockssh"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
 )
 
 func TestValkey(t *testing.T) {
+	t.Parallel()
 	authServer := mockapi.NewAuthServer(t)
 	defer authServer.Close()
 
@@ -29,25 +26,8 @@ func TestValkey(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	relationships := map[string]any{
-		"cache": []map[string]any{{
-			"username": nil,
-			"host":     "cache.internal",
-			"path":     nil,
-			"query":    url.Values{},
-			"password": nil,
-			"port":     6379,
-			"service":  "cache",
-			"scheme":   "valkey",
-			"type":     "valkey:8.0",
-			"public":   false,
-		}},
-	}
-	relationshipsJSON, err := json.Marshal(relationships)
-	require.NoError(t, err)
-
 	execHandler := mockssh.ExecHandler(t.TempDir(), []string{
-		"PLATFORM_RELATIONSHIPS=" + base64.StdEncoding.EncodeToString(relationshipsJSON),
+		relationshipsFixture{}.With("cache", valkeyRelationship("cache")).EnvVar(),
 	})
 
 	sshServer.CommandHandler = func(conn ssh.ConnMetadata, command string, io mockssh.CommandIO) int {
@@ -102,8 +82,6 @@ func TestValkey(t *testing.T) {
 		EnvPrefix + "SSH_HOST_KEYS=" + sshServer.HostKeyConfig(),
 	}
 
-	f.Run("cc")
-
 	assert.Equal(t, "Received command: valkey-cli -h cache.internal -p 6379 ping",
 		f.Run("valkey", "-p", projectID, "-e", ".", "ping"))
 
@@ -8,6 +8,7 @@ import (
 )
 
 func TestVariableList(t *testing.T) {
+	t.Parallel()
 	authServer := mockapi.NewAuthServer(t)
 	defer authServer.Close()
 
@@ -58,7 +59,6 @@ func TestVariableList(t *testing.T) {
 
 	f := newCommandFactory(t, apiServer.URL, authServer.URL)
 
-	f.Run("cc")
 	assertTrimmed(t, `
 +---------+-------------+---------------------------+---------+
 | Name    | Level       | Value                     | Enabled |
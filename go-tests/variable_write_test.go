@@ -1,61 +1,22 @@
 package tests
 
 import (
-	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/platformsh/cli/pkg/mockapi"
 	"github.com/stretchr/testify/assert"
 )
 
-// variableTestSetup holds common test infrastructure for variable tests.
-type variableTestSetup struct {
-	authServer *httptest.Server
-	apiServer  *httptest.Server
-	apiHandler *mockapi.Handler
-	projectID  string
-	mainEnv    *mockapi.Environment
-	factory    *cmdFactory
-}
-
-// setupVariableTest creates the common test infrastructure for variable tests.
-func setupVariableTest(t *testing.T) *variableTestSetup {
-	authServer := mockapi.NewAuthServer(t)
-	t.Cleanup(authServer.Close)
-
-	apiHandler := mockapi.NewHandler(t)
-	apiServer := httptest.NewServer(apiHandler)
-	t.Cleanup(apiServer.Close)
-
-	projectID := mockapi.ProjectID()
-
-	apiHandler.SetProjects([]*mockapi.Project{{
-		ID: projectID,
-		Links: mockapi.MakeHALLinks(
-			"self=/projects/"+projectID,
-			"environments=/projects/"+projectID+"/environments",
-		),
-		DefaultBranch: "main",
-	}})
-
-	mainEnv := makeEnv(projectID, "main", "production", "active", nil)
-	mainEnv.Links["#variables"] = mockapi.HALLink{HREF: "/projects/" + projectID + "/environments/main/variables"}
-	mainEnv.Links["#manage-variables"] = mockapi.HALLink{HREF: "/projects/" + projectID + "/environments/main/variables"}
-
-	return &variableTestSetup{
-		authServer: authServer,
-		apiServer:  apiServer,
-		apiHandler: apiHandler,
-		projectID:  projectID,
-		mainEnv:    mainEnv,
-		factory:    newCommandFactory(t, apiServer.URL, authServer.URL),
-	}
+// setupVariableTest creates a scenario with a "main" environment which supports variables.
+func setupVariableTest(t *testing.T) *scenario {
+	return newScenario(t).WithEnv("main", "production", "active", nil, envVariables)
 }
 
 func TestVariableCreate(t *testing.T) {
+	t.Parallel()
 	s := setupVariableTest(t)
-	s.apiHandler.SetEnvironments([]*mockapi.Environment{s.mainEnv})
-	s.apiHandler.SetProjectVariables(s.projectID, []*mockapi.Variable{
+	s.Handler.SetProjectVariables(s.ProjectID, []*mockapi.Variable{
 		{
 			Name:         "existing",
 			IsSensitive:  true,
@@ -63,7 +24,7 @@ func TestVariableCreate(t *testing.T) {
 		},
 	})
 
-	f, p := s.factory, s.projectID
+	f, p := s.Factory(), s.ProjectID
 
 	_, stdErr, err := f.RunCombinedOutput("var:create", "-p", p, "-l", "e", "-e", "main", "env:TEST", "--value", "env-level-value")
 	assert.NoError(t, err)
@@ -92,21 +53,81 @@ func TestVariableCreate(t *testing.T) {
 	assertTrimmed(t, "false", f.Run("var:get", "-p", p, "env:TEST", "-l", "p", "-P", "visible_runtime"))
 }
 
+func TestVariableUpdateRequests(t *testing.T) {
+	t.Parallel()
+	s := setupVariableTest(t)
+
+	f, p := s.Factory(), s.ProjectID
+
+	_, _, err := f.RunCombinedOutput("var:create", "-p", p, "-l", "p", "env:TEST", "--value", "test-value")
+	assert.NoError(t, err)
+	body := s.Recorder.RequireOne(t, "POST", "/projects/"+p+"/variables").JSON(t)
+	assert.Equal(t, "env:TEST", body["name"])
+	assert.Equal(t, "test-value", body["value"])
+
+	// Only the changed property should be sent.
+	s.Recorder.Reset()
+	_, _, err = f.RunCombinedOutput("var:update", "-p", p, "-l", "p", "env:TEST", "--visible-runtime", "false")
+	assert.NoError(t, err)
+	s.Recorder.AssertOneJSON(t, "PATCH", "/variables/env:TEST", `{"visible_runtime": false}`)
+	s.Recorder.AssertNone(t, "POST", "/variables")
+	s.Recorder.AssertNone(t, "DELETE", "/variables/env:TEST")
+
+	// Nothing should be sent if nothing changed.
+	s.Recorder.Reset()
+	_, stdErr, err := f.RunCombinedOutput("var:update", "-p", p, "-l", "p", "env:TEST", "--visible-runtime", "false")
+	assert.Error(t, err)
+	assert.Contains(t, stdErr, "No changes were provided.")
+	s.Recorder.AssertNone(t, "PATCH", "/variables/env:TEST")
+
+	for _, r := range s.Recorder.Requests() {
+		assert.Equal(t, "Bearer", strings.SplitN(r.Header.Get("Authorization"), " ", 2)[0], "authorization header of %s", r)
+	}
+}
+
+func TestVariableUpdateWait(t *testing.T) {
+	t.Parallel()
+	s := setupVariableTest(t).WithActivitySimulator()
+	s.WithCapabilities("main", envActivities)
+	s.Sim.On("POST", `/environments/main/variables$`, activitySpec{
+		Type:        "environment.variable.create",
+		Description: "<user>Mock User</user> created variable <variable>env:TEST</variable> on environment <environment>main</environment>",
+		Steps:       activitySucceeds("Redeploying environment main"),
+	})
+	s.Sim.On("PATCH", `/environments/main/variables/env:TEST$`, activitySpec{
+		Type:        "environment.variable.update",
+		Description: "<user>Mock User</user> updated variable <variable>env:TEST</variable> on environment <environment>main</environment>",
+		Steps:       activityFails("Redeploying environment main", "  E: Error starting app"),
+	})
+	f, p := s.Factory(), s.ProjectID
+
+	// An environment-level change waits for the redeployment.
+	_, stdErr, err := f.RunCombinedOutput("var:create", "-p", p, "-l", "e", "-e", "main", "env:TEST", "--value", "new-value")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "The activity succeeded: [sim1] Mock User created variable env:TEST on environment main")
+	assert.Equal(t, "complete", s.Sim.State("sim1"))
+
+	_, stdErr, err = f.RunCombinedOutput("var:update", "-p", p, "-l", "e", "-e", "main", "env:TEST", "--value", "newer-value")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "E: Error starting app")
+	assert.Contains(t, stdErr, "The activity failed: [sim2] Mock User updated variable env:TEST on environment main")
+
+	// A project-level change does not trigger a redeployment.
+	_, stdErr, err = f.RunCombinedOutput("var:create", "-p", p, "-l", "p", "env:OTHER", "--value", "project-value")
+	assert.NoError(t, err)
+	assert.NotContains(t, stdErr, "Waiting for the activity")
+	assert.Len(t, s.Sim.IDs(), 2)
+}
+
 func TestVariableCreateWithAppScope(t *testing.T) {
+	t.Parallel()
 	s := setupVariableTest(t)
 
 	// Set up deployment with app names for validation.
-	s.mainEnv.SetCurrentDeployment(&mockapi.Deployment{
-		WebApps: map[string]mockapi.App{
-			"app1": {Name: "app1", Type: "golang:1.23"},
-			"app2": {Name: "app2", Type: "php:8.3"},
-		},
-		Routes: make(map[string]any),
-		Links:  mockapi.MakeHALLinks("self=/projects/" + s.projectID + "/environments/main/deployment/current"),
-	})
-	s.apiHandler.SetEnvironments([]*mockapi.Environment{s.mainEnv})
+	s.WithApp("main", mockapi.App{Name: "app1", Type: "golang:1.23"}).
+		WithApp("main", mockapi.App{Name: "app2", Type: "php:8.3"})
 
-	f, p := s.factory, s.projectID
+	f, p := s.Factory(), s.ProjectID
 
 	// Test creating project-level variable with single app-scope.
 	_, stdErr, err := f.RunCombinedOutput("var:create", "-p", p, "-l", "p",
@@ -143,11 +164,11 @@ func TestVariableCreateWithAppScope(t *testing.T) {
 }
 
 func TestVariableCreateWithAppScopeNoDeployment(t *testing.T) {
+	t.Parallel()
 	// Uses an environment without a deployment, so app-scope validation is skipped.
 	s := setupVariableTest(t)
-	s.apiHandler.SetEnvironments([]*mockapi.Environment{s.mainEnv})
 
-	f, p := s.factory, s.projectID
+	f, p := s.Factory(), s.ProjectID
 
 	// Without a deployment, any app-scope value should be accepted.
 	_, stdErr, err := f.RunCombinedOutput("var:create", "-p", p, "-l", "p",
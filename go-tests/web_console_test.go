@@ -9,6 +9,7 @@ import (
 )
 
 func TestWebConsole(t *testing.T) {
+	t.Parallel()
 	authServer := mockapi.NewAuthServer(t)
 	defer authServer.Close()
 
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookDelivery(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t).
+		WithEnv("main", "production", "active", nil, envActivities, envRedeploy).
+		WithEnv("dev", "development", "active", "main", envActivities, envRedeploy).
+		WithWebhookReceiver()
+	s.Sim.On("POST", `/environments/main/redeploy$`, activitySpec{
+		Type:        "environment.redeploy",
+		Description: "<user>Mock User</user> redeployed environment <environment>main</environment>",
+	})
+	s.Sim.On("POST", `/environments/dev/redeploy$`, activitySpec{
+		Type:        "environment.redeploy",
+		Description: "<user>Mock User</user> redeployed environment <environment>dev</environment>",
+	})
+	// A webhook for another environment, which does not receive main's activities.
+	s.WithIntegrations(&integrationFixture{ID: "devhook", Type: "webhook", Values: map[string]any{
+		"url":          s.Webhooks.URL,
+		"environments": []any{"dev"},
+	}})
+	f, p := s.Factory(), s.ProjectID
+
+	_, stdErr, err := f.RunCombinedOutput("integration:add", "-p", p, "--type", "webhook", "--url", s.Webhooks.URL, "--events", "environment.redeploy")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Created integration int2 (type: webhook)")
+	assert.Empty(t, s.Webhooks.Received())
+
+	// Other events are not sent.
+	push := s.Sim.Start(activitySpec{Type: "environment.push", Description: "<user>Mock User</user> pushed to <environment>main</environment>"}, "main")
+	_, _, err = f.RunCombinedOutput("activity:log", "-p", p, push)
+	assert.NoError(t, err)
+	assert.Empty(t, s.Webhooks.Received())
+
+	// The activity is sent when it completes.
+	_, _, err = f.RunCombinedOutput("redeploy", "-p", p, "-e", "main")
+	require.NoError(t, err)
+	received := s.Webhooks.Received()
+	require.Len(t, received, 1)
+	assert.Equal(t, "application/json", received[0].Header.Get("Content-Type"))
+	assert.Equal(t, "sim2", received[0].Payload["id"])
+	assert.Equal(t, "environment.redeploy", received[0].Payload["type"])
+	assert.Equal(t, "complete", received[0].Payload["state"])
+	assert.Equal(t, "success", received[0].Payload["result"])
+	assert.Equal(t, []any{"main"}, received[0].Payload["environments"])
+
+	stdOut, stdErr, err := f.RunCombinedOutput("integration:activity:log", "-p", p, "int2")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "Integration ID: int2")
+	assert.Contains(t, stdErr, "Type: integration.webhook")
+	assert.Equal(t, "Sending activity sim2 (environment.redeploy, complete) to "+s.Webhooks.URL+"\nReceived response: 200 OK\n", stdOut)
+
+	_, stdErr, err = f.RunCombinedOutput("integration:activities", "-p", p, "devhook")
+	assertExitCode(t, 1, err)
+	assert.Contains(t, stdErr, "No activities found")
+
+	// Both webhooks receive dev's activities, and failed deliveries are logged.
+	s.Webhooks.RespondWith(http.StatusServiceUnavailable)
+	_, _, err = f.RunCombinedOutput("redeploy", "-p", p, "-e", "dev")
+	require.NoError(t, err)
+	assert.Len(t, s.Webhooks.Received(), 3)
+	assertTrimmed(t, "failure", f.Run("integration:activity:get", "-p", p, "devhook", "-P", "result"))
+	assert.Contains(t, f.Run("integration:activity:log", "-p", p, "int2"), "Received response: 503 Service Unavailable")
+}
+
+func TestWebhookValidate(t *testing.T) {
+	t.Parallel()
+	s := newScenario(t).WithWebhookReceiver()
+	closed := newWebhookReceiver(t)
+	closed.Close()
+	f, p := s.Factory(), s.ProjectID
+
+	_, _, err := f.RunCombinedOutput("integration:add", "-p", p, "--type", "webhook", "--url", s.Webhooks.URL)
+	require.NoError(t, err)
+
+	_, stdErr, err := f.RunCombinedOutput("integration:validate", "-p", p, "int1")
+	assert.NoError(t, err)
+	assert.Contains(t, stdErr, "The integration is valid.")
+
+	s.Webhooks.RespondWith(http.StatusNotFound)
+	stdOut, _, err := f.RunCombinedOutput("integration:validate", "-p", p, "int1")
+	assertExitCode(t, 4, err)
+	assertTrimmed(t, "url: The URL returned HTTP status 404.", stdOut)
+
+	stdOut, stdErr, err = f.RunCombinedOutput("integration:add", "-p", p, "--type", "webhook", "--url", closed.URL)
+	assertExitCode(t, 4, err)
+	assert.Contains(t, stdErr, "The integration is invalid.")
+	assert.Contains(t, stdOut, "url: Could not reach the URL")
+
+	// Checking the URL does not deliver anything.
+	assert.Empty(t, s.Webhooks.Received())
+}
@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookReceiver is a local HTTP server which stands in for the URL of a
+// webhook integration, and records the payloads it receives.
+type webhookReceiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	status   int
+	received []receivedWebhook
+}
+
+// receivedWebhook is a request received by a webhookReceiver.
+type receivedWebhook struct {
+	Header  http.Header
+	Payload map[string]any
+}
+
+func newWebhookReceiver(t *testing.T) *webhookReceiver {
+	wr := &webhookReceiver{status: http.StatusOK}
+	wr.Server = httptest.NewServer(http.HandlerFunc(wr.serveHTTP))
+	t.Cleanup(wr.Close)
+	return wr
+}
+
+// RespondWith sets the HTTP status of the receiver's responses (200 by default).
+func (wr *webhookReceiver) RespondWith(status int) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	wr.status = status
+}
+
+// Received returns the webhooks received so far.
+func (wr *webhookReceiver) Received() []receivedWebhook {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	return slices.Clone(wr.received)
+}
+
+// serveHTTP records POST requests. Other requests (e.g. the HEAD request
+// which checks the URL) are only answered.
+func (wr *webhookReceiver) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	if req.Method == http.MethodPost {
+		var payload map[string]any
+		_ = json.NewDecoder(req.Body).Decode(&payload)
+		wr.received = append(wr.received, receivedWebhook{Header: req.Header.Clone(), Payload: payload})
+	}
+	w.WriteHeader(wr.status)
+}
+
+// WithWebhookReceiver starts a webhookReceiver (s.Webhooks), and makes the
+// mock API deliver activities to webhook integrations, as the platform does.
+//
+// Each time a simulated activity changes state, it is sent to the URL of each
+// webhook integration whose events, states and environments match, and an
+// "integration.webhook" activity on the integration logs the delivery. The
+// URLs of webhook integrations are also checked when they are validated.
+func (s *scenario) WithWebhookReceiver() *scenario {
+	s.Webhooks = newWebhookReceiver(s.t)
+	if s.Sim == nil {
+		s.WithActivitySimulator()
+	}
+	s.WithIntegrations()
+	s.Sim.OnChange(s.deliverWebhooks)
+	return s
+}
+
+var webhookClient = &http.Client{Timeout: 5 * time.Second}
+
+// checkWebhookURL checks that a webhook URL can be reached.
+func checkWebhookURL(url string) string {
+	resp, err := webhookClient.Head(url)
+	if err != nil {
+		return "Could not reach the URL: " + err.Error()
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Sprintf("The URL returned HTTP status %d.", resp.StatusCode)
+	}
+	return ""
+}
+
+// deliverWebhooks sends an activity to the matching webhook integrations.
+// Integration activities themselves are not sent.
+func (s *scenario) deliverWebhooks(activity map[string]any) {
+	if _, ok := activity["integration"]; ok {
+		return
+	}
+	type target struct{ id, url string }
+	var targets []target
+	s.integrations.mu.Lock()
+	for _, i := range s.integrations.integrations {
+		if i.Type == "webhook" && webhookMatches(i, activity) {
+			url, _ := i.Values["url"].(string)
+			targets = append(targets, target{i.ID, url})
+		}
+	}
+	s.integrations.mu.Unlock()
+
+	for _, t := range targets {
+		step := activityStep{State: "complete", Result: "success", CompletionPercent: 100}
+		step.Log = []string{fmt.Sprintf("Sending activity %s (%s, %s) to %s", activity["id"], activity["type"], activity["state"], t.url)}
+		body, _ := json.Marshal(activity)
+		resp, err := webhookClient.Post(t.url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			step.Result = "failure"
+			step.Log = append(step.Log, "Error: "+err.Error())
+		} else {
+			resp.Body.Close()
+			step.Log = append(step.Log, "Received response: "+resp.Status)
+			if resp.StatusCode >= 300 {
+				step.Result = "failure"
+			}
+		}
+		s.Sim.Start(activitySpec{
+			Type:        "integration.webhook",
+			Description: fmt.Sprintf("Sending activity %s to <integration>%s</integration>", activity["id"], t.url),
+			Payload:     map[string]any{"activity": activity["id"]},
+			Integration: t.id,
+			Steps:       []activityStep{step},
+		})
+	}
+}
+
+// webhookMatches checks an activity against a webhook integration's events,
+// states and environments. The platform's defaults apply to unset lists.
+func webhookMatches(i *integrationFixture, activity map[string]any) bool {
+	list := func(name string, fallback ...string) []string {
+		v, ok := i.Values[name]
+		if !ok {
+			return fallback
+		}
+		return stringList(v)
+	}
+	events := list("events", "*")
+	if !slices.Contains(events, "*") && !slices.Contains(events, activity["type"].(string)) {
+		return false
+	}
+	if !slices.Contains(list("states", "complete"), activity["state"].(string)) {
+		return false
+	}
+	included, excluded := list("environments", "*"), list("excluded_environments")
+	envs := stringList(activity["environments"])
+	if slices.ContainsFunc(envs, func(env string) bool { return slices.Contains(excluded, env) }) {
+		return false
+	}
+	return slices.Contains(included, "*") ||
+		slices.ContainsFunc(envs, func(env string) bool { return slices.Contains(included, env) })
+}
+
+// stringList converts a list of strings, as decoded from JSON or set in a
+// fixture, to a []string.
+func stringList(v any) []string {
+	switch v := v.(type) {
+	case []string:
+		return v
+	case []any:
+		var list []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
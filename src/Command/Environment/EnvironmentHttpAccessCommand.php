@@ -183,6 +183,10 @@ class EnvironmentHttpAccessCommand extends CommandBase
         } elseif ($auth !== []) {
             foreach (array_filter($auth) as $auth) {
                 $parsed = $this->parseAuth($auth);
+                if (isset($accessOpts['basic_auth'][$parsed['username']])) {
+                    $message = sprintf('The username "<error>%s</error>" is specified more than once for --auth', $parsed['username']);
+                    throw new InvalidArgumentException($message);
+                }
                 $accessOpts['basic_auth'][$parsed['username']] = $parsed['password'];
             }
             $change = true;